@@ -0,0 +1,292 @@
+// Package cdx reads Visual FoxPro compound index files (.CDX)
+package cdx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	pageSize   = 512
+	headerSize = 1024
+)
+
+// Index options stored in the tag header
+const (
+	optionUnique    = 0x01
+	optionNullable  = 0x02
+	optionCandidate = 0x04
+	optionFor       = 0x08
+	optionCompact   = 0x20
+	optionCompound  = 0x40
+)
+
+// Node attributes
+const (
+	nodeRoot = 0x01
+	nodeLeaf = 0x02
+)
+
+// ErrTagNotFound is returned when a tag does not exist in the index
+var ErrTagNotFound = errors.New("Tag not found")
+
+// Index is a compound index containing one or more tags
+type Index struct {
+	r      io.ReaderAt
+	closer io.Closer
+	tags   []*Tag
+}
+
+// Open opens the compound index file at `path`
+func Open(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := NewIndex(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("Could not read index %q. %w", path, err)
+	}
+	idx.closer = f
+	return idx, nil
+}
+
+// NewIndex reads a compound index from `r`
+func NewIndex(r io.ReaderAt) (*Index, error) {
+	dir, err := readTag(r, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	if (dir.options & optionCompound) == 0 {
+		return nil, fmt.Errorf("Not a compound index")
+	}
+
+	idx := &Index{r: r}
+	err = dir.walkRaw(func(key []byte, offset uint32) error {
+		t, err := readTag(r, int64(offset), string(bytes.TrimRight(key, " \x00")))
+		if err != nil {
+			return err
+		}
+		idx.tags = append(idx.tags, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// Close closes the underlying file if the index was opened with Open
+func (idx *Index) Close() error {
+	if idx.closer != nil {
+		return idx.closer.Close()
+	}
+	return nil
+}
+
+// Tags returns all tags of the index
+func (idx *Index) Tags() []*Tag {
+	return idx.tags
+}
+
+// Tag returns the tag with the specified name (Case insensitive)
+func (idx *Index) Tag(name string) (*Tag, error) {
+	for _, t := range idx.tags {
+		if strings.EqualFold(t.Name, name) {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("%w %q", ErrTagNotFound, name)
+}
+
+// Tag is a single index order inside a compound index
+type Tag struct {
+	Name       string
+	KeyExpr    string
+	ForExpr    string
+	KeyLength  int
+	Descending bool
+	Unique     bool
+	Candidate  bool
+	// Nullable keys start with an additional byte, 0x80 for values and 0x00 for NULL
+	Nullable bool
+
+	r       io.ReaderAt
+	root    uint32
+	options byte
+	keyType rune
+
+	storedOnce       sync.Once
+	storedDescending bool
+	storedErr        error
+}
+
+func readTag(r io.ReaderAt, offset int64, name string) (*Tag, error) {
+	buf := make([]byte, headerSize)
+	if _, err := r.ReadAt(buf, offset); err != nil {
+		return nil, fmt.Errorf("Could not read tag header at %d. %w", offset, err)
+	}
+	t := &Tag{
+		Name:      name,
+		KeyLength: int(binary.LittleEndian.Uint16(buf[0x0C:])),
+		r:         r,
+		root:      binary.LittleEndian.Uint32(buf[0x00:]),
+		options:   buf[0x0E],
+		keyType:   'C',
+	}
+	if (t.options & optionCompact) == 0 {
+		return nil, fmt.Errorf("Tag %q: only compact indexes are supported", name)
+	}
+	if t.KeyLength <= 0 || t.KeyLength > pageSize {
+		return nil, fmt.Errorf("Tag %q: invalid key length %d", name, t.KeyLength)
+	}
+	t.Descending = binary.LittleEndian.Uint16(buf[0x1F6:]) != 0
+	t.Unique = (t.options & optionUnique) != 0
+	t.Candidate = (t.options & optionCandidate) != 0
+	t.Nullable = (t.options & optionNullable) != 0
+
+	pool := buf[0x200:]
+	t.ForExpr = expression(pool, binary.LittleEndian.Uint16(buf[0x1F8:]), binary.LittleEndian.Uint16(buf[0x1FA:]))
+	t.KeyExpr = expression(pool, binary.LittleEndian.Uint16(buf[0x1FC:]), binary.LittleEndian.Uint16(buf[0x1FE:]))
+	return t, nil
+}
+
+// expression returns the null terminated expression stored in the expression pool
+func expression(pool []byte, pos, length uint16) string {
+	if int(pos)+int(length) > len(pool) || length == 0 {
+		return ""
+	}
+	expr := pool[pos : pos+length]
+	if i := bytes.IndexByte(expr, 0x00); i >= 0 {
+		expr = expr[:i]
+	}
+	return string(expr)
+}
+
+// SetKeyType sets the type of the key expression's result.
+// It decides which byte is used to restore trailing bytes that are stripped from stored keys:
+// blanks for character keys ('C', the default), zero bytes for all other types.
+func (t *Tag) SetKeyType(typ rune) {
+	t.keyType = typ
+}
+
+// trail returns the byte that restores stripped trailing bytes
+func (t *Tag) trail() byte {
+	if t.keyType == 'C' {
+		return ' '
+	}
+	return 0x00
+}
+
+type node struct {
+	tag    *Tag
+	offset uint32
+	buf    []byte
+	attr   uint16
+	count  int
+	left   uint32
+	right  uint32
+
+	// keys and recnos hold the decompressed entries of a leaf node
+	keys   [][]byte
+	recnos []uint32
+}
+
+func (t *Tag) readNode(offset uint32) (*node, error) {
+	buf := make([]byte, pageSize)
+	if _, err := t.r.ReadAt(buf, int64(offset)); err != nil {
+		return nil, fmt.Errorf("Could not read node at %d. %w", offset, err)
+	}
+	n := &node{
+		tag:    t,
+		offset: offset,
+		buf:    buf,
+		attr:   binary.LittleEndian.Uint16(buf[0:]),
+		count:  int(binary.LittleEndian.Uint16(buf[2:])),
+		left:   binary.LittleEndian.Uint32(buf[4:]),
+		right:  binary.LittleEndian.Uint32(buf[8:]),
+	}
+	if n.leaf() {
+		if n.count*int(buf[23]) > pageSize-24 {
+			return nil, fmt.Errorf("Corrupt leaf node at %d", offset)
+		}
+		var err error
+		if n.keys, n.recnos, err = n.entries(); err != nil {
+			return nil, fmt.Errorf("Corrupt leaf node at %d. %w", offset, err)
+		}
+	} else if 12+n.count*(t.KeyLength+8) > pageSize {
+		return nil, fmt.Errorf("Corrupt index node at %d", offset)
+	}
+	return n, nil
+}
+
+func (n *node) leaf() bool {
+	return (n.attr & nodeLeaf) != 0
+}
+
+// key returns the key of entry `i` of an interior node
+func (n *node) key(i int) []byte {
+	pos := 12 + i*(n.tag.KeyLength+8)
+	return n.buf[pos : pos+n.tag.KeyLength]
+}
+
+// child returns the child node offset of entry `i` of an interior node
+func (n *node) child(i int) uint32 {
+	pos := 12 + i*(n.tag.KeyLength+8) + n.tag.KeyLength + 4
+	return binary.BigEndian.Uint32(n.buf[pos:])
+}
+
+// entries decompresses the keys of a leaf node
+func (n *node) entries() ([][]byte, []uint32, error) {
+	buf := n.buf
+	recMask := uint64(binary.LittleEndian.Uint32(buf[14:]))
+	dupMask := uint64(buf[18])
+	trailMask := uint64(buf[19])
+	recBits := buf[20]
+	dupBits := buf[21]
+	entrySize := int(buf[23])
+	keyLen := n.tag.KeyLength
+
+	if entrySize == 0 || entrySize > 8 {
+		return nil, nil, fmt.Errorf("Invalid entry size %d", entrySize)
+	}
+
+	keys := make([][]byte, n.count)
+	recnos := make([]uint32, n.count)
+	data := make([]byte, n.count*keyLen)
+	prev := []byte(nil)
+	trailByte := n.tag.trail()
+	keyPos := pageSize
+	for i := 0; i < n.count; i++ {
+		var v uint64
+		entry := buf[24+i*entrySize : 24+(i+1)*entrySize]
+		for j := len(entry) - 1; j >= 0; j-- {
+			v = v<<8 | uint64(entry[j])
+		}
+		dup := int((v >> recBits) & dupMask)
+		trail := int((v >> (recBits + dupBits)) & trailMask)
+		newLen := keyLen - dup - trail
+		if dup > len(prev) || newLen < 0 || keyPos-newLen < 24+n.count*entrySize {
+			return nil, nil, fmt.Errorf("Invalid entry %d", i)
+		}
+		keyPos -= newLen
+
+		key := data[i*keyLen : (i+1)*keyLen]
+		copy(key, prev[:dup])
+		copy(key[dup:], buf[keyPos:keyPos+newLen])
+		for j := keyLen - trail; j < keyLen; j++ {
+			key[j] = trailByte
+		}
+		keys[i] = key
+		recnos[i] = uint32(v & recMask)
+		prev = key
+	}
+	return keys, recnos, nil
+}
@@ -0,0 +1,290 @@
+package cdx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	idx, err := Open("../test/contacts.cdx")
+	if err != nil {
+		t.Fatalf("Could not open index. %v", err)
+	}
+	defer idx.Close()
+
+	if len(idx.Tags()) != 4 {
+		t.Fatalf("Expected 4 tags, got %d", len(idx.Tags()))
+	}
+	tag, err := idx.Tag("by_name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag.KeyExpr != "UPPER(last_name)+UPPER(first_name)" || tag.KeyLength != 101 || !tag.Nullable || tag.Candidate {
+		t.Errorf("Unexpected tag %+v", tag)
+	}
+	pk, err := idx.Tag("CONTACT_ID")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pk.Candidate || pk.Unique || pk.Descending {
+		t.Errorf("Unexpected tag %+v", pk)
+	}
+	if _, err := idx.Tag("missing"); err == nil {
+		t.Errorf("Expected an error for a missing tag")
+	}
+}
+
+func TestWalk(t *testing.T) {
+	idx, err := Open("../test/contacts.cdx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	tag, _ := idx.Tag("BY_NAME")
+	var names []string
+	var recnos []uint32
+	err = tag.Walk(func(key []byte, recno uint32) error {
+		names = append(names, string(bytes.Fields(key[1:])[0]))
+		recnos = append(recnos, recno)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"BUCHANAN", "DERP", "FULLER", "LEVERLING", "PEACOCK"}
+	expectedRecnos := []uint32{4, 0, 2, 1, 3}
+	for i := range expected {
+		if names[i] != expected[i] || recnos[i] != expectedRecnos[i] {
+			t.Errorf("Entry %d: expected %s/%d, got %s/%d", i, expected[i], expectedRecnos[i], names[i], recnos[i])
+		}
+	}
+
+	pk, _ := idx.Tag("CONTACT_ID")
+	pk.SetKeyType('I')
+	err = pk.Walk(func(key []byte, recno uint32) error {
+		if id := binary.BigEndian.Uint32(key) ^ 0x80000000; id != recno+1 {
+			t.Errorf("Expected id %d for record %d, got %d", recno+1, recno, id)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWalkMultipleLevels(t *testing.T) {
+	idx, err := Open("../test/contacts.dcx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	tag, err := idx.Tag("OBJECTNAME")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag.ForExpr != ".NOT.DELETED()" {
+		t.Errorf("Unexpected FOR expression %q", tag.ForExpr)
+	}
+	var prev []byte
+	count := 0
+	err = tag.Walk(func(key []byte, recno uint32) error {
+		if bytes.Compare(prev, key) > 0 {
+			t.Errorf("Keys out of order: %q > %q", prev, key)
+		}
+		prev = append(prev[:0], key...)
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 58 {
+		t.Errorf("Expected 58 keys, got %d", count)
+	}
+}
+
+func TestRangeAndSeek(t *testing.T) {
+	idx, err := Open("../test/accounts.cdx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	tag, _ := idx.Tag("PRIMARYKEY")
+	tag.SetKeyType('I')
+	from, err := tag.EncodeKey(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, _ := tag.EncodeKey(4)
+	var recnos []uint32
+	err = tag.Range(from, to, func(key []byte, recno uint32) error {
+		recnos = append(recnos, recno)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recnos) != 3 || recnos[0] != 1 || recnos[2] != 3 {
+		t.Errorf("Unexpected range %v", recnos)
+	}
+
+	key, _ := tag.EncodeKey(9)
+	if recno, found, err := tag.Seek(key); err != nil || !found || recno != 8 {
+		t.Errorf("Expected to find record 8, got %d %v %v", recno, found, err)
+	}
+	key, _ = tag.EncodeKey(10)
+	if _, found, err := tag.Seek(key); err != nil || found {
+		t.Errorf("Expected no match for key 10, got %v %v", found, err)
+	}
+
+	names, _ := idx.Tag("ACCOUNTNAM")
+	if recno, found, _ := names.Seek([]byte("Mu")); !found || recno != 6 {
+		t.Errorf("Expected to find record 6 for partial key, got %d %v", recno, found)
+	}
+}
+
+func TestDescendingRange(t *testing.T) {
+	idx, err := Open("../test/contacts.dcx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	// Stored in ascending order, but read as a descending tag
+	tag, _ := idx.Tag("OBJECTTYPE")
+	tag.Descending = true
+
+	var prev []byte
+	count := 0
+	err = tag.Walk(func(key []byte, recno uint32) error {
+		if prev != nil && bytes.Compare(prev, key) < 0 {
+			t.Errorf("Keys out of order: %q < %q", prev, key)
+		}
+		prev = append(prev[:0], key...)
+		count++
+		return nil
+	})
+	if err != nil || count != 58 {
+		t.Fatalf("Expected 58 keys, got %d %v", count, err)
+	}
+
+	from := []byte("         1Table")
+	to := []byte("         1Database")
+	var keys []string
+	err = tag.Range(from, to, func(key []byte, recno uint32) error {
+		keys = append(keys, string(key[10:]))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) == 0 || keys[0] != "Table     " || keys[len(keys)-1] != "Database  " {
+		t.Errorf("Unexpected descending range %q", keys)
+	}
+}
+
+func TestCorruptLeaf(t *testing.T) {
+	b, err := os.ReadFile("../test/contacts.cdx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := NewIndex(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, _ := idx.Tag("BY_NAME")
+	offset := tag.root
+	n, err := tag.readNode(offset)
+	for err == nil && !n.leaf() {
+		offset = n.child(0)
+		n, err = tag.readNode(offset)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first entry claims to share bytes with a previous key
+	page := b[offset : offset+pageSize]
+	recBits := page[20]
+	entrySize := int(page[23])
+	var v uint64
+	for j := entrySize - 1; j >= 0; j-- {
+		v = v<<8 | uint64(page[24+j])
+	}
+	v |= uint64(page[18]) << recBits
+	for j := 0; j < entrySize; j++ {
+		page[24+j] = byte(v >> (8 * j))
+	}
+
+	idx, err = NewIndex(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, _ = idx.Tag("BY_NAME")
+	if err := tag.Walk(func(key []byte, recno uint32) error { return nil }); err == nil {
+		t.Errorf("Expected an error for a corrupt leaf")
+	}
+	if _, _, err := tag.Seek([]byte("FULLER")); err == nil {
+		t.Errorf("Expected an error for a corrupt leaf")
+	}
+}
+
+func FuzzIndex(f *testing.F) {
+	b, err := os.ReadFile("../test/contacts.cdx")
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(b)
+	f.Fuzz(func(t *testing.T, b []byte) {
+		idx, err := NewIndex(bytes.NewReader(b))
+		if err != nil {
+			return
+		}
+		for _, tag := range idx.Tags() {
+			tag.Walk(func(key []byte, recno uint32) error { return nil })
+			tag.Seek([]byte("F"))
+		}
+	})
+}
+
+func TestNodeLoops(t *testing.T) {
+	b, err := os.ReadFile("../test/contacts.cdx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The directory leaf is its own right sibling
+	loop := append([]byte(nil), b...)
+	binary.LittleEndian.PutUint32(loop[1024+8:], 1024)
+	if _, err := NewIndex(bytes.NewReader(loop)); err == nil {
+		t.Errorf("Expected an error for a sibling loop")
+	}
+
+	// The root of BY_NAME becomes an interior node that points to itself
+	idx, err := NewIndex(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, _ := idx.Tag("BY_NAME")
+	loop = append([]byte(nil), b...)
+	root := loop[tag.root : tag.root+pageSize]
+	binary.LittleEndian.PutUint16(root[0:], 0)
+	binary.LittleEndian.PutUint16(root[2:], 1)
+	binary.BigEndian.PutUint32(root[12+tag.KeyLength+4:], tag.root)
+	idx, err = NewIndex(bytes.NewReader(loop))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, _ = idx.Tag("BY_NAME")
+	if err := tag.Walk(func(key []byte, recno uint32) error { return nil }); err == nil {
+		t.Errorf("Expected an error for a child loop")
+	}
+	if _, _, err := tag.Seek([]byte("F")); err == nil {
+		t.Errorf("Expected an error for a child loop")
+	}
+}
@@ -0,0 +1,279 @@
+package cdx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+const noSibling = 0xFFFFFFFF
+
+// maxDepth limits the descent to a leaf, so corrupt child pointers can not loop
+const maxDepth = 32
+
+// errStop ends a walk early
+var errStop = errors.New("stop")
+
+// cursor points to an entry of a leaf node
+type cursor struct {
+	tag    *Tag
+	n      *node
+	keys   [][]byte
+	recnos []uint32
+	i      int
+	err    error
+
+	// seen holds the nodes visited since the cursor last changed direction, a sibling chain must not repeat them
+	seen    map[uint32]bool
+	forward bool
+}
+
+func (t *Tag) newCursor(n *node) *cursor {
+	c := &cursor{tag: t}
+	c.load(n)
+	return c
+}
+
+func (c *cursor) load(n *node) {
+	c.n = n
+	c.keys, c.recnos = n.keys, n.recnos
+}
+
+// sibling loads the sibling at `offset`, reached by moving `forward` or backward
+func (c *cursor) sibling(offset uint32, forward bool) bool {
+	if c.seen == nil || c.forward != forward {
+		c.seen = map[uint32]bool{c.n.offset: true}
+		c.forward = forward
+	}
+	if c.seen[offset] {
+		c.err, c.n = fmt.Errorf("Sibling of node at %d loops back to node at %d", c.n.offset, offset), nil
+		return false
+	}
+	c.seen[offset] = true
+	n, err := c.tag.readNode(offset)
+	if err != nil {
+		c.err, c.n = err, nil
+		return false
+	}
+	c.load(n)
+	return true
+}
+
+func (c *cursor) valid() bool {
+	return c.err == nil && c.n != nil && c.i >= 0 && c.i < len(c.recnos)
+}
+
+// next moves to the following entry in stored order
+func (c *cursor) next() {
+	c.i++
+	for c.n != nil && c.i >= len(c.recnos) {
+		if c.n.right == noSibling {
+			c.n = nil
+			return
+		}
+		if !c.sibling(c.n.right, true) {
+			return
+		}
+		c.i = 0
+	}
+}
+
+// prev moves to the preceding entry in stored order
+func (c *cursor) prev() {
+	c.i--
+	for c.n != nil && c.i < 0 {
+		if c.n.left == noSibling {
+			c.n = nil
+			return
+		}
+		if !c.sibling(c.n.left, false) {
+			return
+		}
+		c.i = len(c.recnos) - 1
+	}
+}
+
+// edge returns a cursor to the first (`last` = false) or last entry in stored order
+func (t *Tag) edge(last bool) (*cursor, error) {
+	n, err := t.readNode(t.root)
+	if err != nil {
+		return nil, err
+	}
+	for depth := 0; !n.leaf(); depth++ {
+		if depth >= maxDepth {
+			return nil, fmt.Errorf("Index node at %d is deeper than %d levels", n.offset, maxDepth)
+		}
+		if n.count == 0 {
+			return &cursor{tag: t}, nil
+		}
+		child := 0
+		if last {
+			child = n.count - 1
+		}
+		if n, err = t.readNode(n.child(child)); err != nil {
+			return nil, err
+		}
+	}
+	c := t.newCursor(n)
+	if last {
+		c.i = len(c.recnos)
+		c.prev()
+	} else if len(c.recnos) == 0 {
+		c.next()
+	}
+	return c, c.err
+}
+
+// locate returns a cursor to the first entry in stored order, whose key prefix is not before `key`.
+// With `after` set, the cursor points to the first entry that is after `key`.
+// If there is no such entry, the cursor is positioned behind the last entry.
+func (t *Tag) locate(key []byte, after bool) (*cursor, error) {
+	matches := func(k []byte) bool {
+		cmp := t.compare(k, key)
+		return cmp > 0 || (cmp == 0 && !after)
+	}
+	n, err := t.readNode(t.root)
+	if err != nil {
+		return nil, err
+	}
+	for depth := 0; !n.leaf(); depth++ {
+		if depth >= maxDepth {
+			return nil, fmt.Errorf("Index node at %d is deeper than %d levels", n.offset, maxDepth)
+		}
+		if n.count == 0 {
+			return &cursor{tag: t}, nil
+		}
+		// Interior keys are the last key of their child
+		child := n.count - 1
+		for i := 0; i < n.count; i++ {
+			if matches(n.key(i)) {
+				child = i
+				break
+			}
+		}
+		if n, err = t.readNode(n.child(child)); err != nil {
+			return nil, err
+		}
+	}
+	c := t.newCursor(n)
+	for c.i < len(c.recnos) && !matches(c.keys[c.i]) {
+		c.i++
+	}
+	if c.i == len(c.recnos) && n.right != noSibling {
+		c.i--
+		c.next()
+	}
+	return c, c.err
+}
+
+// compare compares the prefix of the stored key `k` with `key`, in stored order
+func (t *Tag) compare(k, key []byte) int {
+	if len(k) > len(key) {
+		k = k[:len(key)]
+	}
+	cmp := bytes.Compare(k, key)
+	if t.storedDescending {
+		return -cmp
+	}
+	return cmp
+}
+
+// detectStoredOrder checks whether keys are physically stored in descending order
+func (t *Tag) detectStoredOrder() error {
+	t.storedOnce.Do(func() {
+		if !t.Descending {
+			return
+		}
+		first, err := t.edge(false)
+		if err != nil {
+			t.storedErr = err
+			return
+		}
+		last, err := t.edge(true)
+		if err != nil {
+			t.storedErr = err
+			return
+		}
+		if first.valid() && last.valid() {
+			t.storedDescending = bytes.Compare(first.keys[first.i], last.keys[last.i]) > 0
+		}
+	})
+	return t.storedErr
+}
+
+// Walk follows the chain of leaf nodes and passes every decompressed key of the tag in index order.
+// Record numbers are passed zero based for dbf.Dbf.RecordAt, FoxPro stores them from 1.
+// The key is only valid until walk returns.
+func (t *Tag) Walk(walk func(key []byte, recno uint32) error) error {
+	return t.Range(nil, nil, walk)
+}
+
+// Range walks the keys of the tag from `from` up to and including `to`, a nil bound is open.
+// Keys are binary (see EncodeKey) and match by prefix, so partial character keys work like SEEK with SET EXACT OFF.
+// For descending tags `from` is the larger key, whichever order FoxPro stored the leaves in.
+func (t *Tag) Range(from, to []byte, walk func(key []byte, recno uint32) error) error {
+	return t.rangeRaw(from, to, func(key []byte, recno uint32) error {
+		return walk(key, recno-1)
+	})
+}
+
+// Seek returns the zero based record number of the first key of the tag that starts with `key`, see EncodeKey
+func (t *Tag) Seek(key []byte) (uint32, bool, error) {
+	var recno uint32
+	found := false
+	err := t.Range(key, key, func(_ []byte, r uint32) error {
+		recno = r
+		found = true
+		return errStop
+	})
+	if err == errStop {
+		err = nil
+	}
+	return recno, found, err
+}
+
+func (t *Tag) walkRaw(walk func(key []byte, recno uint32) error) error {
+	return t.rangeRaw(nil, nil, walk)
+}
+
+func (t *Tag) rangeRaw(from, to []byte, walk func(key []byte, recno uint32) error) error {
+	if err := t.detectStoredOrder(); err != nil {
+		return err
+	}
+	forward := t.Descending == t.storedDescending
+
+	var c *cursor
+	var err error
+	switch {
+	case from == nil:
+		c, err = t.edge(!forward)
+	case forward:
+		c, err = t.locate(from, false)
+	default:
+		if c, err = t.locate(from, true); err == nil {
+			c.prev()
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	for c.valid() {
+		key := c.keys[c.i]
+		if to != nil {
+			cmp := t.compare(key, to)
+			if (forward && cmp > 0) || (!forward && cmp < 0) {
+				break
+			}
+		}
+		if err := walk(key, c.recnos[c.i]); err != nil {
+			return err
+		}
+		if forward {
+			c.next()
+		} else {
+			c.prev()
+		}
+	}
+	return c.err
+}
@@ -0,0 +1,95 @@
+package cdx
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Kirides/go-dbf/internal/keyvalue"
+)
+
+// EncodeKey converts a value into the binary key format of the tag.
+// Character keys take a string or []byte that is already in the code page of the table.
+// Numeric keys ('N', 'F', 'B', 'I') take integers or floats, date keys ('D', 'T') a time.Time
+// and logical keys ('L') a bool. nil seeks NULL keys of nullable tags.
+func (t *Tag) EncodeKey(v interface{}) ([]byte, error) {
+	key, err := t.encodeValue(v)
+	if err != nil {
+		return nil, err
+	}
+	if t.Nullable {
+		prefix := byte(0x80)
+		if v == nil {
+			prefix = 0x00
+		}
+		key = append([]byte{prefix}, key...)
+	}
+	if len(key) > t.KeyLength {
+		return nil, fmt.Errorf("Key exceeds the key length of %d", t.KeyLength)
+	}
+	return key, nil
+}
+
+func (t *Tag) encodeValue(v interface{}) ([]byte, error) {
+	if v == nil {
+		if !t.Nullable {
+			return nil, fmt.Errorf("Tag %q does not contain NULL keys", t.Name)
+		}
+		return []byte{}, nil
+	}
+	switch t.keyType {
+	case 'C':
+		switch s := v.(type) {
+		case string:
+			return []byte(s), nil
+		case []byte:
+			return s, nil
+		}
+	case 'I':
+		if i, ok := keyvalue.Float(v); ok && i >= math.MinInt32 && i <= math.MaxInt32 && i == math.Trunc(i) {
+			key := make([]byte, 4)
+			binary.BigEndian.PutUint32(key, uint32(int32(i))^0x80000000)
+			return key, nil
+		}
+	case 'N', 'F', 'B':
+		if f, ok := keyvalue.Float(v); ok {
+			return sortableFloat(f), nil
+		}
+	case 'D', 'T':
+		if d, ok := v.(time.Time); ok {
+			jd := float64(keyvalue.JulianDay(d))
+			if t.keyType == 'T' {
+				jd += float64(d.Hour()*3600+d.Minute()*60+d.Second()) / 86400
+			}
+			return sortableFloat(jd), nil
+		}
+	case 'L':
+		if b, ok := v.(bool); ok {
+			if b {
+				return []byte{'T'}, nil
+			}
+			return []byte{'F'}, nil
+		}
+	default:
+		return nil, fmt.Errorf("Tag %q: unsupported key type %q", t.Name, t.keyType)
+	}
+	return nil, fmt.Errorf("Tag %q: invalid key %v (%T) for key type %q", t.Name, v, v, t.keyType)
+}
+
+// sortableFloat stores a float64 in big endian byte order, so that its bytes sort like its value
+func sortableFloat(f float64) []byte {
+	if f == 0 {
+		// Normalize -0
+		f = 0
+	}
+	bits := math.Float64bits(f)
+	if (bits & (1 << 63)) == 0 {
+		bits |= 1 << 63
+	} else {
+		bits = ^bits
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, bits)
+	return key
+}
@@ -0,0 +1,124 @@
+package dbf
+
+import (
+	"bytes"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+// codePageMarks maps the code page mark of the header to the Windows/DOS code page
+var codePageMarks = map[byte]int{
+	0x01: 437, 0x02: 850, 0x03: 1252, 0x04: 10000,
+	0x08: 865, 0x09: 437, 0x0A: 850, 0x0B: 437, 0x0D: 437, 0x0E: 850, 0x0F: 437,
+	0x10: 850, 0x11: 437, 0x12: 850, 0x13: 932, 0x14: 850, 0x15: 437, 0x16: 850, 0x17: 865,
+	0x18: 437, 0x19: 437, 0x1A: 850, 0x1B: 437, 0x1C: 863, 0x1D: 850, 0x1F: 852,
+	0x22: 852, 0x23: 852, 0x24: 860, 0x25: 850, 0x26: 866, 0x37: 850,
+	0x40: 852, 0x4D: 936, 0x4E: 949, 0x4F: 950, 0x50: 874, 0x57: 1252, 0x58: 1252, 0x59: 1252,
+	0x64: 852, 0x65: 866, 0x66: 865, 0x67: 861, 0x6A: 737, 0x6B: 857, 0x6C: 863,
+	0x78: 950, 0x79: 949, 0x7A: 936, 0x7B: 932, 0x7C: 874, 0x7D: 1255, 0x7E: 1256,
+	0x86: 737, 0x87: 852, 0x88: 857,
+	0x96: 10007, 0x97: 10029, 0x98: 10006,
+	0xC8: 1250, 0xC9: 1251, 0xCA: 1254, 0xCB: 1253, 0xCC: 1257,
+}
+
+// codePageEncodings holds the code pages that golang.org/x/text supports
+var codePageEncodings = map[int]encoding.Encoding{
+	437:   charmap.CodePage437,
+	850:   charmap.CodePage850,
+	852:   charmap.CodePage852,
+	860:   charmap.CodePage860,
+	863:   charmap.CodePage863,
+	865:   charmap.CodePage865,
+	866:   charmap.CodePage866,
+	874:   charmap.Windows874,
+	932:   japanese.ShiftJIS,
+	936:   simplifiedchinese.GBK,
+	949:   korean.EUCKR,
+	950:   traditionalchinese.Big5,
+	1250:  charmap.Windows1250,
+	1251:  charmap.Windows1251,
+	1252:  charmap.Windows1252,
+	1253:  charmap.Windows1253,
+	1254:  charmap.Windows1254,
+	1255:  charmap.Windows1255,
+	1256:  charmap.Windows1256,
+	1257:  charmap.Windows1257,
+	10000: charmap.Macintosh,
+	10007: charmap.MacintoshCyrillic,
+}
+
+// CodePageOf returns the Windows/DOS code page of a code page mark or 0 if the mark is unknown
+func CodePageOf(mark byte) int {
+	return codePageMarks[mark]
+}
+
+// EncodingOf returns the text encoding of a code page mark or nil if the mark is unknown or its code page is not supported
+func EncodingOf(mark byte) encoding.Encoding {
+	return codePageEncodings[codePageMarks[mark]]
+}
+
+// CodePage returns the Windows/DOS code page used to decode text or 0 if it is not known.
+// Marks of unsupported code pages (e.g. 0x6A, CP737) report the code page of the fallback, see OpenAuto.
+// Use CodePageOf for the code page announced by the header.
+func (dbf *Dbf) CodePage() int {
+	return codePageOfEncoding(dbf.encoding)
+}
+
+// Encoding returns the encoding used to decode text,
+// or nil if the decoder passed to Open does not match any known encoding
+func (dbf *Dbf) Encoding() encoding.Encoding {
+	return dbf.encoding
+}
+
+// codePageOfEncoding returns the Windows/DOS code page of a supported encoding or 0
+func codePageOfEncoding(enc encoding.Encoding) int {
+	if enc == nil {
+		return 0
+	}
+	for cp, e := range codePageEncodings {
+		if e == enc {
+			return cp
+		}
+	}
+	return 0
+}
+
+// detectEncoding returns the encoding of the code page mark, `fallback` for unknown marks
+// and Windows-1252 if there is no fallback
+func detectEncoding(mark byte, fallback encoding.Encoding) encoding.Encoding {
+	if enc := EncodingOf(mark); enc != nil {
+		return enc
+	}
+	if fallback != nil {
+		return fallback
+	}
+	return charmap.Windows1252
+}
+
+// encodingFor returns the encoding that decodes like `decoder`, or nil if there is none.
+// The encoding of the code page mark is tried first, then all charmaps.
+func encodingFor(decoder *encoding.Decoder, mark byte) encoding.Encoding {
+	probe := make([]byte, 256)
+	for i := range probe {
+		probe[i] = byte(i)
+	}
+	want, err := decoder.Bytes(probe)
+	if err != nil {
+		return nil
+	}
+	candidates := append([]encoding.Encoding{EncodingOf(mark)}, charmap.All...)
+	for _, enc := range candidates {
+		if enc == nil {
+			continue
+		}
+		if got, err := enc.NewDecoder().Bytes(probe); err == nil && bytes.Equal(got, want) {
+			return enc
+		}
+	}
+	return nil
+}
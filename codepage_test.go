@@ -0,0 +1,111 @@
+package dbf
+
+import (
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestCodePageDetection(t *testing.T) {
+	tbl, err := Open("test/contacts.dbf", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+	if tbl.CodePage() != 1252 {
+		t.Errorf("Expected code page 1252, got %d", tbl.CodePage())
+	}
+
+	dir := t.TempDir()
+	create := func(name string, mark byte, value string) string {
+		path := filepath.Join(dir, name)
+		schema := Schema{CodePage: mark, Fields: []Field{{Name: "NAME", Type: 'C', Length: 10}}}
+		if err := Create(path, schema, charmap.CodePage866.NewEncoder()); err != nil {
+			t.Fatal(err)
+		}
+		tbl, err := OpenReadWrite(path, charmap.CodePage866.NewDecoder(), charmap.CodePage866.NewEncoder())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tbl.Close()
+		if _, err := tbl.Append(map[string]interface{}{"NAME": value}); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	cyrillic := create("cyrillic.dbf", 0x65, "Привет")
+	tbl, err = OpenReadWrite(cyrillic, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tbl.CodePage() != 866 {
+		t.Errorf("Expected code page 866, got %d", tbl.CodePage())
+	}
+	if _, err := tbl.Append(map[string]interface{}{"NAME": "Мир"}); err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range []string{"Привет", "Мир"} {
+		if m, _ := recordMap(t, tbl, uint32(i)); m["NAME"] != expected {
+			t.Errorf("Expected %q, got %q", expected, m["NAME"])
+		}
+	}
+	tbl.Close()
+
+	unknown := create("unknown.dbf", 0xEE, "Привет")
+	tbl, err = OpenAuto(unknown, charmap.CodePage866)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+	if m, _ := recordMap(t, tbl, 0); tbl.CodePage() != 866 || m["NAME"] != "Привет" {
+		t.Errorf("Expected the fallback to decode %q, got %q (code page %d)", "Привет", m["NAME"], tbl.CodePage())
+	}
+
+	// CP737 is announced but not supported, the fallback decodes
+	greek := create("greek.dbf", 0x6A, "Привет")
+	tbl, err = OpenAuto(greek, charmap.CodePage866)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+	if CodePageOf(0x6A) != 737 || tbl.CodePage() != 866 || tbl.Encoding() != charmap.CodePage866 {
+		t.Errorf("Expected code page 866 for the unsupported mark, got %d", tbl.CodePage())
+	}
+	if info := tbl.Info(); info.CodePageMark != 0x6A || info.CodePage != 866 {
+		t.Errorf("Unexpected code page 0x%02X %d", info.CodePageMark, info.CodePage)
+	}
+
+	// an explicit decoder wins over the mark
+	tbl, err = Open("test/contacts.dbf", charmap.CodePage850.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+	if tbl.CodePage() != 850 {
+		t.Errorf("Expected code page 850 of the decoder, got %d", tbl.CodePage())
+	}
+	tbl, err = Open("test/contacts.dbf", unicode.UTF8.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+	if tbl.CodePage() != 0 || tbl.Encoding() != nil {
+		t.Errorf("Expected no code page for UTF-8, got %d", tbl.CodePage())
+	}
+}
+
+func TestEncodingFor(t *testing.T) {
+	enc := encodingFor(charmap.CodePage850.NewDecoder(), 0x00)
+	if enc == nil {
+		t.Fatal("Expected an encoding for CP850")
+	}
+	if b, err := enc.NewEncoder().Bytes([]byte("Ü")); err != nil || string(b) != "\x9a" {
+		t.Errorf("Expected the CP850 encoding, got %x %v", b, err)
+	}
+	if enc := encodingFor(unicode.UTF8.NewDecoder(), 0x03); enc != nil {
+		t.Errorf("Expected no encoding for UTF-8")
+	}
+}
@@ -0,0 +1,267 @@
+package dbf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding"
+)
+
+const (
+	memoHeaderSize       = 512
+	defaultMemoBlockSize = 64
+)
+
+// Schema describes the structure of a new table
+type Schema struct {
+	// Type of the table. TypeNone picks the matching Visual FoxPro type.
+	Type Type
+	// CodePage is the code page mark stored in the header
+	CodePage byte
+	// Fields in their physical order.
+	// Displacement and Index are calculated, the length of fixed size types is set automatically.
+	// A `_NullFlags` field is appended for nullable, varchar and varbinary fields.
+	Fields []Field
+}
+
+// Create creates a new, empty table at the specified path.
+// A memo file (.FPT) is created alongside, if any field stores its data in memo blocks.
+// Existing files are truncated.
+func Create(path string, schema Schema, encoder *encoding.Encoder) error {
+	header, fields, err := schema.layout()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := writeHeader(w, header); err != nil {
+		f.Close()
+		return fmt.Errorf("Could not write header. %w", err)
+	}
+	if err := writeFields(w, fields, encoder); err != nil {
+		f.Close()
+		return fmt.Errorf("Could not write field structure. %w", err)
+	}
+	if header.Type.isVisualFoxPro() {
+		if _, err := w.Write(make([]byte, maxBacklinkLenght)); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.WriteByte(eofMarker); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if header.hasMemo() {
+		return createMemo(memoPathFor(path), defaultMemoBlockSize)
+	}
+	return nil
+}
+
+// createMemo creates an empty FPT with the specified block size
+func createMemo(path string, blockSize uint16) error {
+	buf := make([]byte, memoHeaderSize)
+	binary.BigEndian.PutUint32(buf[0:], uint32((memoHeaderSize+int(blockSize)-1)/int(blockSize)))
+	binary.BigEndian.PutUint16(buf[6:], blockSize)
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// memoPathFor returns the memo file path for a new table.
+// The extension keeps the case of the table's extension.
+func memoPathFor(path string) string {
+	ext := filepath.Ext(path)
+	memoExt := ".FPT"
+	if strings.EqualFold(ext, ".DBC") {
+		memoExt = ".DCT"
+	}
+	if ext == strings.ToLower(ext) {
+		memoExt = strings.ToLower(memoExt)
+	}
+	return strings.TrimSuffix(path, ext) + memoExt
+}
+
+func (s Schema) layout() (Header, []Field, error) {
+	typ := s.Type
+	if typ == TypeNone {
+		typ = visualFoxProTypeFor(s.Fields)
+	}
+	if typ.isDBase7() {
+		return Header{}, nil, ErrUnsupportedDialect
+	}
+	vfp := typ.isVisualFoxPro()
+
+	fields := make([]Field, 0, len(s.Fields)+1)
+	displacement := uint32(1)
+	nullBits := 0
+	hasMemo := false
+	for i, f := range s.Fields {
+		if (f.Flags & FieldFlagSystem) != 0 {
+			return Header{}, nil, fmt.Errorf("Field %q: system fields can not be specified", f.Name)
+		}
+		if length, ok := fixedFieldLength(f.Type, vfp); ok {
+			f.Length = length
+		} else if !isVariableLengthType(f.Type, vfp) {
+			return Header{}, nil, fmt.Errorf("Field %q: unsupported field type %q", f.Name, f.Type)
+		} else if f.Length == 0 {
+			return Header{}, nil, fmt.Errorf("Field %q: missing length", f.Name)
+		}
+		if (f.Type == 'N' || f.Type == 'F') && f.DecimalCount > 0 && f.DecimalCount+2 > f.Length {
+			return Header{}, nil, fmt.Errorf("Field %q: too many decimals for length %d", f.Name, f.Length)
+		}
+		if !vfp && f.Flags != FieldFlagNone {
+			return Header{}, nil, fmt.Errorf("Field %q: field flags require a Visual FoxPro table", f.Name)
+		}
+		if (f.Flags & FieldFlagAutoInc) == FieldFlagAutoInc {
+			if f.Type != 'I' {
+				return Header{}, nil, fmt.Errorf("Field %q: only integer fields can autoincrement", f.Name)
+			}
+			// Visual FoxPro starts counting at 1 with a step of 1
+			if f.NextAutoIncrement == 0 {
+				f.NextAutoIncrement = 1
+			}
+			if f.AutoIncrementStep == 0 {
+				f.AutoIncrementStep = 1
+			}
+		}
+
+		f.Displacement = displacement
+		f.Index = i
+		f.VarLengthSizeIndex = -1
+		f.NullFieldIndex = -1
+		if f.Type == 'V' || f.Type == 'Q' {
+			f.VarLengthSizeIndex = nullBits
+			nullBits++
+		}
+		if (f.Flags & FieldFlagNull) != 0 {
+			f.NullFieldIndex = nullBits
+			nullBits++
+		}
+		if isMemoType(f.Type) {
+			hasMemo = true
+		}
+
+		displacement += uint32(f.Length)
+		fields = append(fields, f)
+	}
+	if nullBits > 0 {
+		fields = append(fields, Field{
+			Name:               "_NullFlags",
+			Type:               '0',
+			Displacement:       displacement,
+			Length:             byte((nullBits + 7) / 8),
+			Flags:              FieldFlagSystem | FieldFlagBinary,
+			Index:              len(fields),
+			VarLengthSizeIndex: -1,
+			NullFieldIndex:     -1,
+		})
+		displacement += uint32((nullBits + 7) / 8)
+	}
+	if displacement > 0xFFFF {
+		return Header{}, nil, fmt.Errorf("Record length %d exceeds the maximum of %d", displacement, 0xFFFF)
+	}
+
+	headerSize := 32 + 32*len(fields) + 1
+	if vfp {
+		headerSize += maxBacklinkLenght
+	}
+	if headerSize > 0xFFFF {
+		return Header{}, nil, fmt.Errorf("Too many fields (%d)", len(fields))
+	}
+
+	h := Header{
+		Type:         typ,
+		HeaderSize:   uint16(headerSize),
+		RecordLength: uint16(displacement),
+		CodePage:     s.CodePage,
+	}
+	if hasMemo {
+		if !vfp && typ != TypeFoxPro2Memo {
+			return Header{}, nil, fmt.Errorf("Memo fields are not supported for table type 0x%02X", byte(typ))
+		}
+		if vfp {
+			h.Flags |= FlagMemo
+		}
+	}
+	h.setLastModified(time.Now())
+	return h, fields, nil
+}
+
+func visualFoxProTypeFor(fields []Field) Type {
+	typ := TypeVisualFoxPro
+	for _, f := range fields {
+		if f.Type == 'V' || f.Type == 'Q' || f.Type == 'W' {
+			return TypeVisualFoxProVar
+		}
+		if (f.Flags & FieldFlagAutoInc) == FieldFlagAutoInc {
+			typ = TypeVisualFoxProAutoInc
+		}
+	}
+	return typ
+}
+
+// fixedFieldLength returns the storage size of field types that have a fixed length
+func fixedFieldLength(t rune, vfp bool) (byte, bool) {
+	switch t {
+	case 'D':
+		return 8, true
+	case 'L':
+		return 1, true
+	case 'M', 'G':
+		if vfp {
+			return 4, true
+		}
+		return 10, true
+	}
+	if !vfp {
+		return 0, false
+	}
+	switch t {
+	case 'T', 'Y', 'B':
+		return 8, true
+	case 'I', 'W':
+		return 4, true
+	}
+	return 0, false
+}
+
+// isVariableLengthType reports whether the field type takes its length from the schema
+func isVariableLengthType(t rune, vfp bool) bool {
+	switch t {
+	case 'C', 'N', 'F':
+		return true
+	case 'V', 'Q':
+		return vfp
+	}
+	return false
+}
+
+// isMemoType reports whether the field stores its data in the memo file
+func isMemoType(t rune) bool {
+	return t == 'M' || t == 'G' || t == 'W'
+}
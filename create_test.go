@@ -0,0 +1,127 @@
+package dbf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func testSchema() Schema {
+	return Schema{
+		CodePage: 0x03,
+		Fields: []Field{
+			{Name: "ID", Type: 'I'},
+			{Name: "NAME", Type: 'C', Length: 20},
+			{Name: "AMOUNT", Type: 'N', Length: 10, DecimalCount: 2},
+			{Name: "ACTIVE", Type: 'L'},
+			{Name: "BIRTHDAY", Type: 'D'},
+			{Name: "UPDATED", Type: 'T', Flags: FieldFlagNull},
+			{Name: "NOTES", Type: 'M'},
+			{Name: "NICK", Type: 'V', Length: 10, Flags: FieldFlagNull},
+		},
+	}
+}
+
+func TestCreate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "created.dbf")
+	if err := Create(path, testSchema(), charmap.Windows1252.NewEncoder()); err != nil {
+		t.Fatalf("Could not create table. %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(path), "created.fpt")); err != nil {
+		t.Fatalf("Memo file was not created. %v", err)
+	}
+
+	tbl, err := Open(path, charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatalf("Could not open created table. %v", err)
+	}
+	defer tbl.Close()
+
+	h := tbl.Header()
+	if h.Type != TypeVisualFoxProVar || h.RecordCount != 0 || (h.Flags&FlagMemo) == 0 {
+		t.Fatalf("Unexpected header %+v", h)
+	}
+	if h.RecordLength != 1+4+20+10+1+8+8+4+10+1 {
+		t.Fatalf("Unexpected record length %d", h.RecordLength)
+	}
+	if tbl.CalculatedRecordCount() != 0 {
+		t.Fatalf("Unexpected calculated record count %d", tbl.CalculatedRecordCount())
+	}
+
+	want := testSchema().Fields
+	if len(tbl.fields) != len(want)+1 {
+		t.Fatalf("Expected %d fields, got %d", len(want)+1, len(tbl.fields))
+	}
+	for i, f := range want {
+		got := tbl.fields[i]
+		if got.Name != f.Name || got.Type != f.Type || got.Flags != f.Flags {
+			t.Errorf("Field %d: expected %+v, got %+v", i, f, got)
+		}
+	}
+	if tbl.nullField == nil || tbl.nullField.Length != 1 {
+		t.Fatalf("Missing _NullFlags field")
+	}
+	if nick, _ := tbl.FieldByName("NICK"); nick.VarLengthSizeIndex != 1 || nick.NullFieldIndex != 2 {
+		t.Errorf("Unexpected null flag bits for NICK %+v", nick)
+	}
+
+	err = tbl.Scan(func(r *Record) error {
+		t.Errorf("Unexpected record %d", r.Recno())
+		return nil
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateInvalidSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invalid.dbf")
+	schema := Schema{Fields: []Field{{Name: "NAME", Type: 'C'}}}
+	if err := Create(path, schema, charmap.Windows1252.NewEncoder()); err == nil {
+		t.Fatalf("Expected an error for a character field without length")
+	}
+}
+
+func TestDBaseIIILayout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "supplier.dbf")
+	schema := Schema{Type: TypeFoxBasePlusDBaseIII, Fields: []Field{
+		{Name: "CUSTNO", Type: 'N', Length: 6},
+		{Name: "NAME", Type: 'C', Length: 12},
+		{Name: "SINCE", Type: 'D'},
+	}}
+	if err := Create(path, schema, charmap.Windows1252.NewEncoder()); err != nil {
+		t.Fatal(err)
+	}
+	tbl := openTestTableReadWrite(t, path)
+	if _, err := tbl.Append(map[string]interface{}{"CUSTNO": -12, "NAME": "Supplier"}); err != nil {
+		t.Fatal(err)
+	}
+	tbl.Close()
+
+	// dBase III does not use the displacement bytes, some writers leave garbage in them
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(schema.Fields); i++ {
+		copy(b[32+32*i+12:], []byte{0xDE, 0xAD, 0xBE, 0xEF})
+	}
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tbl, err = Open(path, charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+	if tbl.DBC() != "" {
+		t.Errorf("Unexpected backlink %q", tbl.DBC())
+	}
+	m, _ := recordMap(t, tbl, 0)
+	if m["CUSTNO"] != int64(-12) || m["NAME"] != "Supplier" || m["SINCE"] != MinimumDateTime() {
+		t.Errorf("Unexpected record %v", m)
+	}
+}
@@ -0,0 +1,145 @@
+package dbf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"golang.org/x/text/encoding"
+)
+
+const (
+	dBase7HeaderSize = 68
+	dBase7FieldSize  = 48
+
+	fieldPropertiesHeaderSize = 16
+	standardPropertySize      = 15
+	customPropertySize        = 14
+)
+
+// PropertyType identifies a standard dBase 7 field property
+type PropertyType byte
+
+const (
+	// PropertyCustom a custom property with a name
+	PropertyCustom PropertyType = 0x00
+	// PropertyRequired the field is required
+	PropertyRequired PropertyType = 0x01
+	// PropertyMin minimum value
+	PropertyMin PropertyType = 0x02
+	// PropertyMax maximum value
+	PropertyMax PropertyType = 0x03
+	// PropertyDefault default value
+	PropertyDefault PropertyType = 0x04
+	// PropertyConstraint database constraint
+	PropertyConstraint PropertyType = 0x06
+)
+
+// FieldProperty is a field property of a dBase 7 table
+type FieldProperty struct {
+	// Field is the index of the field, -1 for table constraints
+	Field int
+	Type  PropertyType
+	// Name of a custom property
+	Name string
+	// Value in the storage format of the field
+	Value []byte
+}
+
+// LanguageDriver returns the language driver name of a dBase 7 table
+func (dbf *Dbf) LanguageDriver() string {
+	return dbf.languageDriver
+}
+
+// FieldProperties returns the field properties of a dBase 7 table
+func (dbf *Dbf) FieldProperties() []FieldProperty {
+	return dbf.properties
+}
+
+// readDBase7Header reads the language driver name and the field properties following the field descriptors
+func (dbf *Dbf) readDBase7Header(decoder *encoding.Decoder) error {
+	buf := make([]byte, int(dbf.header.HeaderSize))
+	if _, err := dbf.dbfFile.ReadAt(buf, 0); err != nil {
+		return err
+	}
+	dbf.languageDriver, _ = decoder.String(string(trimName(buf[32:64])))
+
+	start := dBase7HeaderSize + dBase7FieldSize*len(dbf.fields) + 1
+	if start+fieldPropertiesHeaderSize > len(buf) {
+		return nil
+	}
+	var err error
+	dbf.properties, err = readFieldProperties(buf[start:], decoder)
+	return err
+}
+
+// readFieldProperties parses the field properties structure. All offsets are relative to its start.
+func readFieldProperties(b []byte, decoder *encoding.Decoder) ([]FieldProperty, error) {
+	u16 := func(i int) int { return int(binary.LittleEndian.Uint16(b[i:])) }
+	data := func(offset, length int) ([]byte, error) {
+		if offset+length > len(b) {
+			return nil, fmt.Errorf("Invalid field property at %d", offset)
+		}
+		return b[offset : offset+length : offset+length], nil
+	}
+
+	standard, standardStart := u16(0), u16(2)
+	custom, customStart := u16(4), u16(6)
+	var props []FieldProperty
+	for i := 0; i < standard; i++ {
+		d := standardStart + i*standardPropertySize
+		if d+standardPropertySize > len(b) {
+			return nil, fmt.Errorf("Invalid field property at %d", d)
+		}
+		value, err := data(u16(d+11), u16(d+13))
+		if err != nil {
+			return nil, err
+		}
+		props = append(props, FieldProperty{Field: u16(d+2) - 1, Type: PropertyType(b[d+4]), Value: value})
+	}
+	for i := 0; i < custom; i++ {
+		d := customStart + i*customPropertySize
+		if d+customPropertySize > len(b) {
+			return nil, fmt.Errorf("Invalid field property at %d", d)
+		}
+		name, err := data(u16(d+6), u16(d+8))
+		if err != nil {
+			return nil, err
+		}
+		value, err := data(u16(d+10), u16(d+12))
+		if err != nil {
+			return nil, err
+		}
+		p := FieldProperty{Field: u16(d+2) - 1, Type: PropertyCustom, Value: value}
+		p.Name, _ = decoder.String(string(name))
+		props = append(props, p)
+	}
+	return props, nil
+}
+
+// dBase 7 stores binary numbers big endian with a flipped sign bit, so they sort like their value
+
+func dBase7Int(b []byte) int32 {
+	return int32(binary.BigEndian.Uint32(b) ^ 0x80000000)
+}
+
+func dBase7Double(b []byte) float64 {
+	u := binary.BigEndian.Uint64(b)
+	if u&(1<<63) != 0 {
+		u ^= 1 << 63
+	} else {
+		u = ^u
+	}
+	return math.Float64frombits(u)
+}
+
+// dBase7Timestamp decodes the milliseconds since 01/01/4713 BC
+func dBase7Timestamp(b []byte) uint64 {
+	if bytes.Equal(b, make([]byte, len(b))) {
+		return 0
+	}
+	ms := dBase7Double(b)
+	days := math.Floor(ms / 86400000)
+	return uint64(ms-days*86400000)<<32 | uint64(uint32(days))
+}
@@ -0,0 +1,120 @@
+package dbf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func dBase7Field(name string, typ byte, length byte) []byte {
+	b := make([]byte, dBase7FieldSize)
+	copy(b, name)
+	b[32] = typ
+	b[33] = length
+	return b
+}
+
+func putDBase7Double(b []byte, f float64) {
+	u := math.Float64bits(f)
+	if f >= 0 {
+		u ^= 1 << 63
+	} else {
+		u = ^u
+	}
+	binary.BigEndian.PutUint64(b, u)
+}
+
+// writeDBase7Table writes a dBase 7 table with one record and a default value property
+func writeDBase7Table(t *testing.T) string {
+	t.Helper()
+	fields := [][]byte{
+		dBase7Field("ID", '+', 4),
+		dBase7Field("CUSTOMER_NAME_LONGER_THAN_TEN", 'C', 8),
+		dBase7Field("BALANCE", 'O', 8),
+		dBase7Field("CREATED", '@', 8),
+		dBase7Field("DELTA", 'I', 4),
+	}
+
+	props := make([]byte, fieldPropertiesHeaderSize+standardPropertySize)
+	binary.LittleEndian.PutUint16(props[0:], 1)
+	binary.LittleEndian.PutUint16(props[2:], fieldPropertiesHeaderSize)
+	binary.LittleEndian.PutUint16(props[12:], uint16(len(props)))
+	d := props[fieldPropertiesHeaderSize:]
+	binary.LittleEndian.PutUint16(d[2:], 2) // second field
+	d[4] = byte(PropertyDefault)
+	binary.LittleEndian.PutUint16(d[11:], uint16(len(props)))
+	binary.LittleEndian.PutUint16(d[13:], 7)
+	props = append(props, "unknown"...)
+	binary.LittleEndian.PutUint16(props[14:], uint16(len(props)))
+
+	var buf bytes.Buffer
+	headerSize := dBase7HeaderSize + dBase7FieldSize*len(fields) + 1 + len(props)
+	h := Header{Type: TypeDBase7, RecordCount: 1, HeaderSize: uint16(headerSize), RecordLength: 1 + 4 + 8 + 8 + 8 + 4, CodePage: 0x03}
+	h.setLastModified(time.Now())
+	writeHeader(&buf, h)
+	driver := make([]byte, 36)
+	copy(driver, "DB437US0")
+	buf.Write(driver)
+	for _, f := range fields {
+		buf.Write(f)
+	}
+	buf.WriteByte(fieldDescriptorTerminator)
+	buf.Write(props)
+
+	record := make([]byte, h.RecordLength)
+	record[0] = ' '
+	binary.BigEndian.PutUint32(record[1:], 7^0x80000000)
+	copy(record[5:], "Nancy   ")
+	putDBase7Double(record[13:], -12.5)
+	putDBase7Double(record[21:], float64(2459000)*86400000+(13*3600+5)*1000) // 2020-05-30 13:00:05
+	binary.BigEndian.PutUint32(record[29:], uint32(0xFFFFFFFF)^0x80000000)   // -1
+	buf.Write(record)
+	buf.WriteByte(eofMarker)
+
+	path := filepath.Join(t.TempDir(), "level7.dbf")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDBase7(t *testing.T) {
+	path := writeDBase7Table(t)
+	tbl, err := Open(path, charmap.CodePage437.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+
+	if tbl.LanguageDriver() != "DB437US0" {
+		t.Errorf("Unexpected language driver %q", tbl.LanguageDriver())
+	}
+	props := tbl.FieldProperties()
+	if len(props) != 1 || props[0].Field != 1 || props[0].Type != PropertyDefault || string(props[0].Value) != "unknown" {
+		t.Errorf("Unexpected field properties %+v", props)
+	}
+
+	m, _ := recordMap(t, tbl, 0)
+	want := map[string]interface{}{
+		"ID":                            int32(7),
+		"CUSTOMER_NAME_LONGER_THAN_TEN": "Nancy",
+		"BALANCE":                       -12.5,
+		"CREATED":                       time.Date(2020, 5, 30, 13, 0, 5, 0, time.Local),
+		"DELTA":                         int32(-1),
+	}
+	for k, v := range want {
+		if m[k] != v {
+			t.Errorf("%s: expected %v, got %v", k, v, m[k])
+		}
+	}
+
+	if _, err := OpenReadWrite(path, charmap.CodePage437.NewDecoder(), charmap.CodePage437.NewEncoder()); err != ErrUnsupportedDialect {
+		t.Errorf("Expected ErrUnsupportedDialect, got %v", err)
+	}
+}
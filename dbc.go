@@ -2,6 +2,7 @@ package dbf
 
 import (
 	"fmt"
+	"io/fs"
 	"strings"
 
 	"golang.org/x/text/encoding"
@@ -13,9 +14,22 @@ func ReadDBC(path string, decoder *encoding.Decoder) (*Dbc, error) {
 		return nil, err
 	}
 	defer dbcDbf.Close()
+	return readDBC(dbcDbf)
+}
+
+// ReadDBCFS reads the DBC `name` of `fsys`
+func ReadDBCFS(fsys fs.FS, name string, decoder *encoding.Decoder) (*Dbc, error) {
+	dbcDbf, err := OpenFS(fsys, name, decoder)
+	if err != nil {
+		return nil, err
+	}
+	defer dbcDbf.Close()
+	return readDBC(dbcDbf)
+}
 
+func readDBC(dbcDbf *Dbf) (*Dbc, error) {
 	tables := make(map[string][]string)
-	tablesByID := make(map[uint32]string)
+	tablesByID := make(map[int32]string)
 
 	objTypeField, err := dbcDbf.FieldByName("OBJECTTYPE")
 	if err != nil {
@@ -51,14 +65,14 @@ func ReadDBC(path string, decoder *encoding.Decoder) (*Dbc, error) {
 				if err != nil {
 					return err
 				}
-				tablesByID[oID.(uint32)] = strings.ToUpper(strings.TrimSpace(oName.(string)))
+				tablesByID[oID.(int32)] = strings.ToUpper(strings.TrimSpace(oName.(string)))
 			} else if oType.(string) == "Field     " {
 				parentID, err := r.FieldAt(parentIDField.Index)
 
 				if err != nil {
 					return err
 				}
-				parentName := tablesByID[parentID.(uint32)]
+				parentName := tablesByID[parentID.(int32)]
 
 				oName, err := r.FieldAt(objNameField.Index)
 				if err != nil {
@@ -8,13 +8,21 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 
+	"github.com/Kirides/go-dbf/cdx"
+	"github.com/Kirides/go-dbf/mdx"
 	"golang.org/x/text/encoding"
 )
 
 const maxBacklinkLenght = 263
 
+// eofMarker terminates the record data
+const eofMarker = 0x1A
+
 // ErrInvalidRecordNumber is returned whenever a provided record number is invalid
 var ErrInvalidRecordNumber = errors.New("Invalid record")
 
@@ -27,57 +35,146 @@ type file interface {
 	Stat() (os.FileInfo, error)
 }
 
+// slicer is implemented by files that can return their content without copying it
+type slicer interface {
+	slice(offset, n int64) []byte
+}
+
 // Dbf provides methods to access a DBF
 type Dbf struct {
-	recpointer    int32
+	recpointer int32
+	storage    storage
+	// closer releases resources that are shared by the table's files, like an archive
+	closer        io.Closer
 	dbfFile       file
 	memoFile      file
 	memoBlockSize int64
+	memoFormat    memoFormat
 	decoder       *encoding.Decoder
+	encoder       *encoding.Encoder
+	encoding      encoding.Encoding
+	writable      bool
+
+	header Header
+	fields []Field
+	// fieldNames maps the upper case field names to their index in fields
+	fieldNames map[string]int
+	backlink   string
+	nullField  *Field
+
+	languageDriver string
+	properties     []FieldProperty
+
+	// mappings caches the struct mappings of Record.Decode by reflect.Type
+	mappings sync.Map
+
+	// indexMu guards opening the indexes, which may happen while records are read concurrently
+	indexMu sync.Mutex
+	// indexKindOnce resolves once whether the index flag refers to a CDX or an MDX
+	indexKindOnce sync.Once
+	mdxIndex      bool
+	// indexOutdated is set once Pack or Zap changed the record numbers the indexes refer to
+	indexOutdated bool
+	cdx           *cdx.Index
+	cdxFile       file
+	mdx           *mdx.Index
+	mdxFile       file
+	order         indexTag
+	orderName     string
+}
 
-	header    Header
-	fields    []Field
-	backlink  string
-	nullField *Field
+// memoExtension returns the extension of the memo file of the table at `path`
+func memoExtension(h Header, path string) string {
+	switch {
+	case h.Type.memoFormat() != memoFPT:
+		return ".DBT"
+	case h.IsDBC() || strings.EqualFold(filepath.Ext(path), ".DBC"):
+		return ".DCT"
+	}
+	return ".FPT"
 }
 
-// Open opens the specifid DBF
+// Open opens the specifid DBF.
+// A nil decoder detects the encoding from the header's code page mark, see OpenAuto.
 func Open(path string, decoder *encoding.Decoder) (*Dbf, error) {
-	osF, err := os.Open(path)
+	return openWith(osStorage{mmap: true}, path, decoder, nil)
+}
+
+// OpenAuto opens the specified DBF and decodes text in the code page announced by the header.
+// Tables with an unknown or unsupported code page mark use `fallback`, or Windows-1252 if it is nil.
+func OpenAuto(path string, fallback encoding.Encoding) (*Dbf, error) {
+	return openWith(osStorage{mmap: true}, path, nil, fallback)
+}
+
+func openWith(s storage, path string, decoder *encoding.Decoder, fallback encoding.Encoding) (*Dbf, error) {
+	dbfFile, err := s.open(path)
 	if err != nil {
 		return nil, err
 	}
-	dbfFile := newMmapFile(osF)
 	dbfHeader := Header{}
 
 	if err := binary.Read(dbfFile, binary.LittleEndian, &dbfHeader); err != nil {
 		dbfFile.Close()
 		return nil, fmt.Errorf("Could not open table at %q. %w", path, err)
 	}
+	var (
+		enc     encoding.Encoding
+		encoder *encoding.Encoder
+	)
+	if decoder == nil {
+		enc = detectEncoding(dbfHeader.CodePage, fallback)
+		decoder = enc.NewDecoder()
+	} else {
+		// index keys need the table's code page, even if the table is only read
+		enc = encodingFor(decoder, dbfHeader.CodePage)
+	}
+	if enc != nil {
+		encoder = enc.NewEncoder()
+	}
 
-	fields, err := readFields(dbfFile, decoder)
+	fields, err := readFields(dbfFile, decoder, &dbfHeader)
 	if err != nil {
 		dbfFile.Close()
 		return nil, fmt.Errorf("Could not read field structure. %w", err)
 	}
 
-	backlinkBuf := make([]byte, 263)
-	if _, err := dbfFile.ReadAt(backlinkBuf, int64(dbfHeader.HeaderSize-maxBacklinkLenght)); err != nil {
-		dbfFile.Close()
-		return nil, fmt.Errorf("Invalid header size. %w", err)
-	}
 	backlink := ""
-	if backlinkBuf[0] != 0x00 {
-		backlink, _ = decoder.String(string(backlinkBuf[:bytes.IndexByte(backlinkBuf, 0x00)]))
+	if dbfHeader.Type.isVisualFoxPro() {
+		// the backlink to the DBC only exists in Visual FoxPro tables
+		if int(dbfHeader.HeaderSize) < 32+32*len(fields)+1+maxBacklinkLenght {
+			dbfFile.Close()
+			return nil, fmt.Errorf("Invalid header size %d", dbfHeader.HeaderSize)
+		}
+		backlinkBuf := make([]byte, maxBacklinkLenght)
+		if _, err := dbfFile.ReadAt(backlinkBuf, int64(dbfHeader.HeaderSize-maxBacklinkLenght)); err != nil {
+			dbfFile.Close()
+			return nil, fmt.Errorf("Invalid header size. %w", err)
+		}
+		if i := bytes.IndexByte(backlinkBuf, 0x00); i != 0 {
+			if i < 0 {
+				i = len(backlinkBuf)
+			}
+			backlink, _ = decoder.String(string(backlinkBuf[:i]))
+		}
 	}
 
 	dbf := &Dbf{
+		storage:  s,
 		dbfFile:  dbfFile,
 		header:   dbfHeader,
 		fields:   fields,
 		backlink: backlink,
 		decoder:  decoder,
+		encoder:  encoder,
+		encoding: enc,
+	}
+	if dbfHeader.Type.isDBase7() {
+		if err := dbf.readDBase7Header(decoder); err != nil {
+			dbfFile.Close()
+			return nil, fmt.Errorf("Could not read dBase 7 header. %w", err)
+		}
 	}
+	dbf.indexFieldNames()
 	for _, f := range dbf.fields {
 		if f.Name == "_NullFlags" {
 			dbf.nullField = &f
@@ -85,36 +182,20 @@ func Open(path string, decoder *encoding.Decoder) (*Dbf, error) {
 		}
 	}
 
-	if (dbfHeader.Flags & FlagMemo) != 0 {
-		ext := strings.ToUpper(filepath.Ext(path))
-		memoExt := ".FPT"
-		if ext == ".DBC" {
-			memoExt = ".DCT"
-		}
-		memoFile := filepath.Base(path)
-		memoFile = memoFile[:strings.LastIndex(memoFile, ".")] + memoExt
-
-		memoFile = filepath.Join(filepath.Dir(path), memoFile)
+	if dbfHeader.hasMemo() {
+		dbf.memoFormat = dbfHeader.Type.memoFormat()
+		memoFile, _ := s.companion(path, memoExtension(dbfHeader, path))
 
-		if memoFile != "" {
-			osM, err := os.Open(memoFile)
-			if err != nil {
-				dbfFile.Close()
-				return nil, err
-			}
-			dbf.memoFile = newMmapFile(osM)
-			if _, err := dbf.memoFile.Seek(6, io.SeekStart); err != nil {
-				dbfFile.Close()
-				dbf.memoFile.Close()
-				return nil, err
-			}
-			intBuf := make([]byte, 2, 2)
-			if _, err := readAll(dbf.memoFile, intBuf); err != nil {
-				dbfFile.Close()
-				dbf.memoFile.Close()
-				return nil, err
-			}
-			dbf.memoBlockSize = int64(binary.BigEndian.Uint16(intBuf))
+		dbf.memoFile, err = s.open(memoFile)
+		if err != nil {
+			dbfFile.Close()
+			return nil, err
+		}
+		dbf.memoBlockSize, err = readMemoBlockSize(dbf.memoFile, dbf.memoFormat)
+		if err != nil {
+			dbfFile.Close()
+			dbf.memoFile.Close()
+			return nil, err
 		}
 	}
 	return dbf, nil
@@ -122,7 +203,7 @@ func Open(path string, decoder *encoding.Decoder) (*Dbf, error) {
 
 // DBC returns the DBF's DBC
 func (dbf *Dbf) DBC() string {
-	if (dbf.header.Flags & FlagDBC) == 0 {
+	if !dbf.header.IsDBC() {
 		return dbf.backlink
 	}
 	return ""
@@ -135,13 +216,13 @@ func (dbf *Dbf) ReadDBC() error {
 		return fmt.Errorf("This table does not belong to a DBC")
 	}
 
-	absPath, err := filepath.Abs(dbf.dbfFile.Name())
+	s := readOnly(dbf.storage)
+	dbcDbf, err := openWith(s, s.resolve(dbf.dbfFile.Name(), dbf.DBC()), dbf.decoder, nil)
 	if err != nil {
 		return err
 	}
-	dbcPath := filepath.Join(filepath.Dir(absPath), dbf.DBC())
-
-	db, err := ReadDBC(dbcPath, dbf.decoder)
+	defer dbcDbf.Close()
+	db, err := readDBC(dbcDbf)
 	if err != nil {
 		return err
 	}
@@ -164,6 +245,12 @@ func (dbf *Dbf) ReadFromDBC(db *Dbc) error {
 	for i, f := range fields {
 		dbf.fields[i].Name = f
 	}
+	dbf.indexFieldNames()
+	// struct mappings depend on the field names
+	dbf.mappings.Range(func(k, _ interface{}) bool {
+		dbf.mappings.Delete(k)
+		return true
+	})
 	return nil
 }
 
@@ -177,6 +264,11 @@ func (dbf *Dbf) Close() error {
 		dbf.memoFile.Close()
 		dbf.memoFile = nil
 	}
+	dbf.closeIndexes()
+	if dbf.closer != nil {
+		dbf.closer.Close()
+		dbf.closer = nil
+	}
 
 	return nil
 }
@@ -194,59 +286,121 @@ const (
 	ParseDefault ParseOption = 0
 	// ParseTrimRight strings.TrimRight(s, " ") is applied to `C`-type fields
 	ParseTrimRight ParseOption = 1 << 0
+	// ParseExactDecimals `N` and `F` fields with decimals are parsed as Decimal instead of float64.
+	// Blank values and the asterisks of an overflowed field are 0 with and without this option, other invalid values are errors.
+	ParseExactDecimals ParseOption = 1 << 1
 )
 
-// RecordAt reads the record at the specified position
+// RecordAt reads the record at the specified position.
+// Reading is safe for concurrent use, as long as the decoder is stateless like all charmap decoders.
+// The error of reading the record is returned after handle, e.g. for truncated tables.
 func (dbf *Dbf) RecordAt(recno uint32, handle func(*Record), options ParseOption) error {
 	if recno >= dbf.header.RecordCount {
 		return ErrInvalidRecordNumber
 	}
 
-	var err error
 	r := newRecord(dbf, recno, options)
-
-	_, err = dbf.dbfFile.Seek(int64(dbf.header.HeaderSize)+(int64(dbf.header.RecordLength)*int64(recno)), io.SeekStart)
-	if err != nil {
-		return fmt.Errorf("Invalid record pointer. %w", err)
-	}
 	handle(r)
 	putBuffer(r.buffer)
-	return nil
+	return r.err
 }
 
 // FieldByName returns a field by it name (Case insensitive)
 func (dbf *Dbf) FieldByName(name string) (Field, error) {
-
-	for i := 0; i < len(dbf.fields); i++ {
-		if strings.EqualFold(dbf.fields[i].Name, name) {
-			return dbf.fields[i], nil
-		}
+	if i := dbf.fieldIndex(name); i >= 0 {
+		return dbf.fields[i], nil
 	}
 	return Field{}, fmt.Errorf("Field not found %q", name)
 }
 
+// indexFieldNames builds the lookup of field names, the first field wins if names collide
+func (dbf *Dbf) indexFieldNames() {
+	dbf.fieldNames = make(map[string]int, len(dbf.fields))
+	for i := len(dbf.fields) - 1; i >= 0; i-- {
+		dbf.fieldNames[strings.ToUpper(dbf.fields[i].Name)] = i
+	}
+}
+
+// fieldIndex returns the index of the field `name` (Case insensitive) or -1
+func (dbf *Dbf) fieldIndex(name string) int {
+	if i, ok := dbf.fieldNames[strings.ToUpper(name)]; ok {
+		return i
+	}
+	return -1
+}
+
 // ScanOffset walks the table starting at `offset` until the end or walk returns a non nil error
 func (dbf *Dbf) ScanOffset(offset uint32, walk func(*Record) error, options ParseOption) error {
-	var err error
-	r := newRecord(dbf, offset, options)
-
-	dbf.dbfFile.Seek(int64(dbf.header.HeaderSize)+(int64(offset)*int64(dbf.header.RecordLength)), io.SeekStart)
+	return dbf.scanRange(offset, dbf.header.RecordCount, walk, options, nil)
+}
 
-	for i := offset; i < dbf.header.RecordCount; i++ {
-		r.recno = i
-		if err = walk(r); err != nil {
+// scanRange walks the records from `from` up to, but not including `to`.
+// It stops early once `stop` is set.
+func (dbf *Dbf) scanRange(from, to uint32, walk func(*Record) error, options ParseOption, stop *atomic.Bool) error {
+	var err error
+	r := newRecord(dbf, from, options)
+	for i := from; i < to; i++ {
+		if stop != nil && stop.Load() {
 			break
 		}
-		if !r.read {
-			dbf.dbfFile.Seek(int64(dbf.header.RecordLength), io.SeekCurrent)
-		}
+		r.recno = i
 		r.read = false
+		r.err = nil
+		if err = walk(r); err == nil {
+			err = r.err
+		}
+		if err != nil {
+			break
+		}
 	}
 	putBuffer(r.buffer)
 	return err
 }
 
-// Scan walks the entire table until the end or walk returns a non nil error
+// ScanParallel walks the entire table with `workers` goroutines, each one scanning a contiguous range of records.
+// Records are passed to walk concurrently and in no particular order, so walk has to be safe for concurrent use.
+// The scan stops at the end or after walk returned a non nil error, which is returned.
+// workers <= 0 uses runtime.GOMAXPROCS(0) goroutines.
+func (dbf *Dbf) ScanParallel(workers int, walk func(*Record) error, options ParseOption) error {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	count := dbf.header.RecordCount
+	if uint32(workers) > count {
+		workers = int(count)
+	}
+	if workers <= 1 {
+		return dbf.Scan(walk, options)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		stop     atomic.Bool
+		errOnce  sync.Once
+		firstErr error
+	)
+	chunk := count / uint32(workers)
+	for w := 0; w < workers; w++ {
+		from := uint32(w) * chunk
+		to := from + chunk
+		if w == workers-1 {
+			to = count
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := dbf.scanRange(from, to, walk, options, &stop); err != nil {
+				errOnce.Do(func() { firstErr = err })
+				stop.Store(true)
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// Scan walks the entire table until the end or walk returns a non nil error.
+// A record that can not be read, e.g. of a truncated table, stops the scan with its error.
 func (dbf *Dbf) Scan(walk func(*Record) error, options ParseOption) error {
 	return dbf.ScanOffset(0, walk, options)
 }
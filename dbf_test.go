@@ -1,8 +1,17 @@
 package dbf
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 
+	"golang.org/x/text/encoding"
 	"golang.org/x/text/encoding/charmap"
 )
 
@@ -59,3 +68,470 @@ func Test_RecordAt(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func Test_Indexes(t *testing.T) {
+	tbl, err := Open(`test/contacts.dbf`, charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.FailNow()
+	}
+	defer tbl.Close()
+
+	idx, err := tbl.Indexes()
+	if err != nil {
+		t.Logf("Failed to open indexes: %v\n", err)
+		t.FailNow()
+	}
+	if len(idx.Tags()) != 4 {
+		t.Logf("Expected 4 tags, got %d\n", len(idx.Tags()))
+		t.FailNow()
+	}
+	if typ, err := tbl.indexKeyType("contact_type_id"); typ != 'I' || err != nil {
+		t.Logf("Expected integer key for contact_type_id\n")
+		t.FailNow()
+	}
+}
+
+func Test_IndexKeyTypes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.dbf")
+	schema := Schema{Fields: []Field{
+		{Name: "NICK", Type: 'V', Length: 20},
+		{Name: "NOTES", Type: 'M'},
+		{Name: "PRICE", Type: 'Y'},
+	}}
+	if err := Create(path, schema, charmap.Windows1252.NewEncoder()); err != nil {
+		t.Fatal(err)
+	}
+	tbl, err := Open(path, charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+	for _, expr := range []string{"NICK", "NOTES", "UPPER(NICK)"} {
+		if typ, err := tbl.indexKeyType(expr); typ != 'C' || err != nil {
+			t.Errorf("%s: expected character keys, got %q %v", expr, typ, err)
+		}
+	}
+	if _, err := tbl.indexKeyType("PRICE"); err == nil {
+		t.Errorf("Expected an error for currency keys")
+	}
+}
+
+func Test_Seek(t *testing.T) {
+	tbl, err := Open(`test/contacts.dbf`, charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.FailNow()
+	}
+	defer tbl.Close()
+
+	if _, _, err := tbl.Seek(1); err != ErrNoOrder {
+		t.Logf("Expected ErrNoOrder, got %v\n", err)
+		t.FailNow()
+	}
+	if err := tbl.SetOrder("contact_id"); err != nil {
+		t.Logf("Failed to set order: %v\n", err)
+		t.FailNow()
+	}
+	recno, found, err := tbl.Seek(3)
+	if err != nil || !found || recno != 2 {
+		t.Logf("Expected to find record 2, got %d %v %v\n", recno, found, err)
+		t.FailNow()
+	}
+	if _, found, _ := tbl.Seek(99); found {
+		t.Logf("Expected no record for key 99\n")
+		t.FailNow()
+	}
+
+	tbl.SetOrder("BY_NAME")
+	recno, found, err = tbl.Seek("FULL")
+	if err != nil || !found || recno != 2 {
+		t.Logf("Expected to find record 2, got %d %v %v\n", recno, found, err)
+		t.FailNow()
+	}
+}
+
+func Test_ScanOrdered(t *testing.T) {
+	tbl, err := Open(`test/contacts.dbf`, charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.FailNow()
+	}
+	defer tbl.Close()
+
+	var names []string
+	err = tbl.ScanOrdered("BY_NAME", "D", "L", func(r *Record) error {
+		v, err := r.FieldAt(2)
+		if err != nil {
+			return err
+		}
+		names = append(names, v.(string))
+		return nil
+	}, ParseTrimRight)
+	if err != nil {
+		t.Logf("Failed to scan: %v\n", err)
+		t.FailNow()
+	}
+	expected := []string{"DERP", "Fuller", "Leverling"}
+	if len(names) != len(expected) {
+		t.Logf("Expected %v, got %v\n", expected, names)
+		t.FailNow()
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Logf("Expected %v, got %v\n", expected, names)
+			t.FailNow()
+		}
+	}
+
+	var recnos []uint32
+	err = tbl.ScanOrdered("TYPE_ID", 1, 1, func(r *Record) error {
+		recnos = append(recnos, r.Recno())
+		return nil
+	}, 0)
+	if err != nil || len(recnos) != 3 {
+		t.Logf("Expected 3 records of type 1, got %v %v\n", recnos, err)
+		t.FailNow()
+	}
+}
+
+// writeMDX writes a production index with a single tag, whose root page is a leaf with the given keys
+func writeMDX(t *testing.T, path, tag, expr string, keyLength int, keys []string, recnos []uint32) {
+	const pageSize = 1024
+	itemSize := (keyLength + 4 + 3) / 4 * 4
+	buf := make([]byte, 4*pageSize)
+	buf[0] = 0x02
+	binary.LittleEndian.PutUint16(buf[20:], 2)
+	binary.LittleEndian.PutUint16(buf[22:], pageSize)
+	buf[24] = 0x01
+	buf[26] = 32
+	binary.LittleEndian.PutUint16(buf[28:], 1)
+	binary.LittleEndian.PutUint32(buf[0x220:], 4) // tag header in page 2
+	copy(buf[0x224:], tag)
+
+	header := buf[2*pageSize:]
+	binary.LittleEndian.PutUint32(header[0:], 6) // root in page 3
+	header[9] = 'C'
+	binary.LittleEndian.PutUint16(header[12:], uint16(keyLength))
+	binary.LittleEndian.PutUint16(header[14:], uint16((pageSize-8)/itemSize-1))
+	binary.LittleEndian.PutUint16(header[18:], uint16(itemSize))
+	copy(header[24:], expr)
+
+	root := buf[3*pageSize:]
+	binary.LittleEndian.PutUint32(root, uint32(len(keys)))
+	for i, k := range keys {
+		binary.LittleEndian.PutUint32(root[8+i*itemSize:], recnos[i]+1)
+		copy(root[12+i*itemSize:], fmt.Sprintf("%-*s", keyLength, k))
+	}
+	if err := os.WriteFile(path, buf, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ProductionIndex(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "parts.dbf")
+	schema := Schema{Type: TypeDBaseIVTable, Fields: []Field{{Name: "NAME", Type: 'C', Length: 8}}}
+	if err := Create(path, schema, charmap.Windows1252.NewEncoder()); err != nil {
+		t.Fatal(err)
+	}
+	tbl := openTestTableReadWrite(t, path)
+	for _, name := range []string{"WHEEL", "AXLE", "SPOKE"} {
+		if _, err := tbl.Append(map[string]interface{}{"NAME": name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tbl.Close()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[28] = byte(FlagMDX)
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeMDX(t, filepath.Join(dir, "parts.mdx"), "NAME", "NAME", 8, []string{"AXLE", "SPOKE", "WHEEL"}, []uint32{1, 2, 0})
+
+	tbl, err = Open(path, charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+	if !tbl.Header().HasMDX() || tbl.Header().HasCDX() {
+		t.Errorf("Expected a production index flag, got 0x%02X", tbl.Header().Flags)
+	}
+	if _, err := tbl.Indexes(); err != ErrNoIndex {
+		t.Errorf("Expected ErrNoIndex for the structural index, got %v", err)
+	}
+	if err := tbl.SetOrder("name"); err != nil {
+		t.Fatal(err)
+	}
+	if tbl.Order() != "NAME" {
+		t.Errorf("Unexpected order %q", tbl.Order())
+	}
+	if recno, found, err := tbl.Seek("SP"); err != nil || !found || recno != 2 {
+		t.Errorf("Expected to find SPOKE at 2, got %d %v %v", recno, found, err)
+	}
+	var names []string
+	err = tbl.ScanOrdered("", nil, nil, func(r *Record) error {
+		v, err := r.Field("NAME")
+		names = append(names, fmt.Sprint(v))
+		return err
+	}, ParseTrimRight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(names) != "[AXLE SPOKE WHEEL]" {
+		t.Errorf("Unexpected order %v", names)
+	}
+}
+
+func Test_ScanParallel(t *testing.T) {
+	path := createTestTable(t)
+	tbl := openTestTableReadWrite(t, path)
+	const count = 1000
+	for i := 0; i < count; i++ {
+		if _, err := tbl.Append(map[string]interface{}{"ID": i, "NOTES": fmt.Sprintf("note %d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tbl.Close()
+
+	tbl, err := Open(path, charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+
+	var mu sync.Mutex
+	seen := make([]int, count)
+	err = tbl.ScanParallel(4, func(r *Record) error {
+		id, err := r.Field("ID")
+		if err != nil {
+			return err
+		}
+		notes, err := r.Field("NOTES")
+		if err != nil {
+			return err
+		}
+		if id.(int32) != int32(r.Recno()) || notes != fmt.Sprintf("note %d", r.Recno()) {
+			return fmt.Errorf("Record %d contains %v %v", r.Recno(), id, notes)
+		}
+		mu.Lock()
+		seen[r.Recno()]++
+		mu.Unlock()
+		return nil
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for recno, n := range seen {
+		if n != 1 {
+			t.Fatalf("Record %d was visited %d times", recno, n)
+		}
+	}
+
+	errStop := fmt.Errorf("stop")
+	if err := tbl.ScanParallel(0, func(r *Record) error { return errStop }, 0); err != errStop {
+		t.Errorf("Expected the walk error, got %v", err)
+	}
+
+	// RecordAt must not depend on a shared file position
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for recno := uint32(w); recno < count; recno += 8 {
+				tbl.RecordAt(recno, func(r *Record) {
+					if id, err := r.Field("ID"); err != nil || id.(int32) != int32(recno) {
+						t.Errorf("Expected ID %d, got %v %v", recno, id, err)
+					}
+				}, 0)
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+func Test_Raw(t *testing.T) {
+	content, err := os.ReadFile(`test/contacts.dbf`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, open := range []func(string, *encoding.Decoder) (*Dbf, error){Open, OpenFile} {
+		tbl, err := open(`test/contacts.dbf`, charmap.Windows1252.NewDecoder())
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := tbl.FieldByName("first_name")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = tbl.Scan(func(r *Record) error {
+			offset := tbl.recordOffset(r.Recno())
+			if !bytes.Equal(r.Raw(), content[offset:offset+int64(tbl.header.RecordLength)]) {
+				t.Errorf("Unexpected raw record %d", r.Recno())
+			}
+			if _, mapped := tbl.dbfFile.(*mmapFile); mapped && &r.Raw()[0] == &r.buffer[0] {
+				t.Errorf("Expected the mapped record instead of a copy")
+			}
+			raw, err := r.RawField(f)
+			if err != nil {
+				return err
+			}
+			v, err := r.FieldAt(f.Index)
+			if err != nil {
+				return err
+			}
+			if string(raw) != v.(string) {
+				t.Errorf("Expected %q, got %q", v, raw)
+			}
+			return nil
+		}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tbl.Close()
+	}
+}
+
+func Test_SeekNonASCII(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "names.dbf")
+	schema := Schema{Type: TypeDBaseIVTable, CodePage: 0x57, Fields: []Field{{Name: "NAME", Type: 'C', Length: 8}}}
+	if err := Create(path, schema, charmap.Windows1252.NewEncoder()); err != nil {
+		t.Fatal(err)
+	}
+	tbl := openTestTableReadWrite(t, path)
+	for _, name := range []string{"MÜLLER", "MAIER"} {
+		if _, err := tbl.Append(map[string]interface{}{"NAME": name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tbl.Close()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[28] = byte(FlagMDX)
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeMDX(t, filepath.Join(dir, "names.mdx"), "NAME", "NAME", 8, []string{"MAIER", "M\xdcLLER"}, []uint32{1, 0})
+
+	// Read only tables have no encoder of their own
+	tbl, err = Open(path, charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+	if err := tbl.SetOrder("NAME"); err != nil {
+		t.Fatal(err)
+	}
+	if recno, found, err := tbl.Seek("MÜLLER"); err != nil || !found || recno != 0 {
+		t.Errorf("Expected to find MÜLLER at 0, got %d %v %v", recno, found, err)
+	}
+
+	// Decoders of unknown encodings can only seek ASCII keys
+	tbl.encoder = nil
+	if _, _, err := tbl.Seek("MÜLLER"); !errors.Is(err, ErrNoEncoder) {
+		t.Errorf("Expected ErrNoEncoder, got %v", err)
+	}
+	if recno, found, err := tbl.Seek("MAIER"); err != nil || !found || recno != 1 {
+		t.Errorf("Expected to find MAIER at 1, got %d %v %v", recno, found, err)
+	}
+}
+
+// countingStorage counts the companion lookups of a storage
+type countingStorage struct {
+	storage
+	lookups int
+}
+
+func (s *countingStorage) companion(name, ext string) (string, bool) {
+	s.lookups++
+	return s.storage.companion(name, ext)
+}
+
+func Test_IndexKindResolvedOnce(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "parts.dbf")
+	if err := Create(path, Schema{Fields: []Field{{Name: "NAME", Type: 'C', Length: 8}}}, charmap.Windows1252.NewEncoder()); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// FoxPro 2 and dBase IV share the type 0x03, the index file decides
+	b[0], b[28] = 0x03, byte(FlagMDX)
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeMDX(t, filepath.Join(dir, "parts.mdx"), "NAME", "NAME", 8, nil, nil)
+
+	tbl, err := Open(path, charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+	s := &countingStorage{storage: tbl.storage}
+	tbl.storage = s
+	for i := 0; i < 3; i++ {
+		if err := tbl.SetOrder("NAME"); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := tbl.Seek("A"); err != nil {
+			t.Fatal(err)
+		}
+		tbl.Info()
+	}
+	// one lookup for the CDX, one to open the MDX
+	if s.lookups != 2 {
+		t.Errorf("Expected 2 companion lookups, got %d", s.lookups)
+	}
+}
+
+func Test_TruncatedTable(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"contacts.dbf", "contacts.FPT"} {
+		b, err := os.ReadFile(filepath.Join("test", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name == "contacts.dbf" {
+			// cut the last record in half, the header still counts it
+			b = b[:len(b)-int(binary.LittleEndian.Uint16(b[10:]))/2]
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, open := range []func(string, *encoding.Decoder) (*Dbf, error){Open, OpenFile} {
+		tbl, err := open(filepath.Join(dir, "contacts.dbf"), charmap.Windows1252.NewDecoder())
+		if err != nil {
+			t.Fatal(err)
+		}
+		last := tbl.Header().RecordCount - 1
+		var read uint32
+		err = tbl.Scan(func(r *Record) error {
+			if _, err := r.ToMap(); err != nil {
+				return err
+			}
+			read++
+			return nil
+		}, 0)
+		if !errors.Is(err, io.EOF) || read != last {
+			t.Errorf("Expected EOF after %d records, got %v after %d", last, err, read)
+		}
+		err = tbl.RecordAt(last, func(r *Record) {
+			if r.Deleted() || r.Raw() != nil {
+				t.Errorf("Expected no data for the truncated record")
+			}
+		}, 0)
+		if !errors.Is(err, io.EOF) {
+			t.Errorf("Expected EOF from RecordAt, got %v", err)
+		}
+		tbl.Close()
+	}
+}
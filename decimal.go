@@ -0,0 +1,167 @@
+package dbf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MaxDecimalScale is the largest number of decimal places of a Decimal, like the decimals of a numeric field
+const MaxDecimalScale = 18
+
+var (
+	errDecimalOverflow = errors.New("Decimal overflows a 64 bit integer")
+	errDecimalScale    = fmt.Errorf("Decimal scale must be between 0 and %d", MaxDecimalScale)
+	errInvalidDecimal  = errors.New("Invalid decimal")
+)
+
+// Decimal is an exact decimal number as stored in `N` and `F` fields.
+// It is returned instead of float64 when ParseExactDecimals is set.
+type Decimal struct {
+	unscaled int64
+	scale    uint8
+}
+
+// NewDecimal returns unscaled * 10^-scale.
+// It panics if scale is not between 0 and MaxDecimalScale.
+func NewDecimal(unscaled int64, scale int) Decimal {
+	if scale < 0 || scale > MaxDecimalScale {
+		panic(errDecimalScale)
+	}
+	return Decimal{unscaled: unscaled, scale: uint8(scale)}
+}
+
+// ParseDecimal parses a decimal number like "-1234.50"
+func ParseDecimal(s string) (Decimal, error) {
+	return parseDecimalBytes([]byte(strings.TrimSpace(s)), 0)
+}
+
+// parseDecimalBytes parses b with at least minScale decimal places
+func parseDecimalBytes(b []byte, minScale uint8) (Decimal, error) {
+	var d Decimal
+	if minScale > MaxDecimalScale {
+		return d, errDecimalScale
+	}
+	neg := false
+	if len(b) > 0 && (b[0] == '-' || b[0] == '+') {
+		neg = b[0] == '-'
+		b = b[1:]
+	}
+	if len(b) == 0 {
+		return d, fmt.Errorf("%w %q", errInvalidDecimal, b)
+	}
+	fraction := false
+	for _, c := range b {
+		if c == '.' && !fraction {
+			fraction = true
+			continue
+		}
+		if c < '0' || c > '9' {
+			return Decimal{}, fmt.Errorf("%w %q", errInvalidDecimal, b)
+		}
+		if d.unscaled > (math.MaxInt64-int64(c-'0'))/10 {
+			return Decimal{}, errDecimalOverflow
+		}
+		d.unscaled = d.unscaled*10 + int64(c-'0')
+		if fraction {
+			if d.scale == MaxDecimalScale {
+				return Decimal{}, errDecimalScale
+			}
+			d.scale++
+		}
+	}
+	for d.scale < minScale {
+		if d.unscaled > math.MaxInt64/10 {
+			return Decimal{}, errDecimalOverflow
+		}
+		d.unscaled *= 10
+		d.scale++
+	}
+	if neg {
+		d.unscaled = -d.unscaled
+	}
+	return d, nil
+}
+
+// Unscaled returns the value without the decimal point
+func (d Decimal) Unscaled() int64 {
+	return d.unscaled
+}
+
+// Scale returns the number of decimal places
+func (d Decimal) Scale() int {
+	return int(d.scale)
+}
+
+// Float64 returns the nearest float64 value
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// Round rounds half away from zero to scale decimal places.
+// It fails if scale is not between 0 and MaxDecimalScale or if the result overflows.
+func (d Decimal) Round(scale int) (Decimal, error) {
+	if scale < 0 || scale > MaxDecimalScale {
+		return Decimal{}, errDecimalScale
+	}
+	for int(d.scale) < scale {
+		if d.unscaled > math.MaxInt64/10 || d.unscaled < math.MinInt64/10 {
+			return Decimal{}, errDecimalOverflow
+		}
+		d.unscaled *= 10
+		d.scale++
+	}
+	if int(d.scale) > scale {
+		// round in one step, rounding digit by digit would round 1.449 up to 1.5
+		div := int64(1)
+		for i := scale; i < int(d.scale); i++ {
+			div *= 10
+		}
+		q, rem := d.unscaled/div, d.unscaled%div
+		if rem >= div-rem {
+			q++
+		} else if -rem >= div+rem {
+			q--
+		}
+		d.unscaled = q
+		d.scale = uint8(scale)
+	}
+	return d, nil
+}
+
+// String formats the decimal with all of its decimal places
+func (d Decimal) String() string {
+	s := strconv.FormatInt(d.unscaled, 10)
+	if d.scale == 0 {
+		return s
+	}
+	sign := ""
+	if d.unscaled < 0 {
+		sign, s = "-", s[1:]
+	}
+	if len(s) <= int(d.scale) {
+		s = strings.Repeat("0", int(d.scale)-len(s)+1) + s
+	}
+	return sign + s[:len(s)-int(d.scale)] + "." + s[len(s)-int(d.scale):]
+}
+
+// Currency is the value of a `Y` field, a 64 bit integer scaled by 10000
+type Currency int64
+
+// Decimal returns the exact value with four decimal places
+func (c Currency) Decimal() Decimal {
+	return NewDecimal(int64(c), 4)
+}
+
+// Float64 returns the nearest float64 value
+func (c Currency) Float64() float64 {
+	return c.Decimal().Float64()
+}
+
+// String formats the value with four decimal places
+func (c Currency) String() string {
+	return c.Decimal().String()
+}
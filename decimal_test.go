@@ -0,0 +1,138 @@
+package dbf
+
+import (
+	"math"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in       string
+		unscaled int64
+		scale    int
+		out      string
+	}{
+		{"1234.50", 123450, 2, "1234.50"},
+		{" -0.05", -5, 2, "-0.05"},
+		{"+7", 7, 0, "7"},
+		{".5", 5, 1, "0.5"},
+	}
+	for _, tt := range tests {
+		d, err := ParseDecimal(tt.in)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.in, err)
+		}
+		if d.Unscaled() != tt.unscaled || d.Scale() != tt.scale || d.String() != tt.out {
+			t.Fatalf("%q: got %d, %d, %s", tt.in, d.Unscaled(), d.Scale(), d)
+		}
+	}
+	for _, in := range []string{"", "-", "1.2.3", "12a", "99999999999999999999"} {
+		if _, err := ParseDecimal(in); err == nil {
+			t.Fatalf("Expected an error for %q", in)
+		}
+	}
+	if d, err := NewDecimal(-12345, 3).Round(2); err != nil || d.String() != "-12.35" {
+		t.Fatalf("Expected -12.35, got %s %v", d, err)
+	}
+	for _, tt := range []struct {
+		d     Decimal
+		scale int
+		out   string
+	}{
+		{NewDecimal(1449, 3), 1, "1.4"},
+		{NewDecimal(1450, 3), 1, "1.5"},
+		{NewDecimal(49, 5), 3, "0.000"},
+		{NewDecimal(50, 5), 3, "0.001"},
+		{NewDecimal(-23449, 4), 2, "-2.34"},
+		{NewDecimal(-23450, 4), 2, "-2.35"},
+		{NewDecimal(math.MaxInt64, 18), 0, "9"},
+		{NewDecimal(math.MinInt64, 18), 17, "-9.22337203685477581"},
+	} {
+		if d, err := tt.d.Round(tt.scale); err != nil || d.String() != tt.out {
+			t.Fatalf("Round(%s, %d): expected %s, got %s %v", tt.d, tt.scale, tt.out, d, err)
+		}
+	}
+	if _, err := NewDecimal(math.MaxInt64/100, 0).Round(4); err != errDecimalOverflow {
+		t.Fatalf("Expected an overflow, got %v", err)
+	}
+	if _, err := NewDecimal(1, 0).Round(19); err != errDecimalScale {
+		t.Fatalf("Expected a scale error, got %v", err)
+	}
+	if _, err := ParseDecimal("0.0000000000000000001"); err != errDecimalScale {
+		t.Fatalf("Expected a scale error, got %v", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("Expected NewDecimal to panic for a scale of 256")
+		}
+	}()
+	NewDecimal(1, 256)
+}
+
+func TestOverflowedNumbers(t *testing.T) {
+	path := createTestTable(t)
+	tbl := openTestTableReadWrite(t, path)
+	if _, err := tbl.Append(map[string]interface{}{"ID": 1, "AMOUNT": 1.5}); err != nil {
+		t.Fatal(err)
+	}
+	f, _ := tbl.FieldByName("AMOUNT")
+	offset := tbl.recordOffset(0) + int64(f.Displacement)
+	tbl.Close()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(b[offset:], strings.Repeat("*", int(f.Length)))
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tbl, err = Open(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+	for _, options := range []ParseOption{ParseDefault, ParseExactDecimals} {
+		err := tbl.RecordAt(0, func(r *Record) {
+			v, err := r.Field("AMOUNT")
+			if err != nil {
+				t.Fatalf("Expected no error for an overflowed field, got %v", err)
+			}
+			if v != float64(0) && v != NewDecimal(0, 2) {
+				t.Errorf("Expected 0, got %#v", v)
+			}
+		}, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSignedNumbers(t *testing.T) {
+	tbl := openTestTableReadWrite(t, createTestTable(t))
+	defer tbl.Close()
+
+	if _, err := tbl.Append(map[string]interface{}{"ID": -42, "AMOUNT": NewDecimal(-123456, 2)}); err != nil {
+		t.Fatal(err)
+	}
+	m, _ := recordMap(t, tbl, 0)
+	if m["ID"] != int32(-42) || m["AMOUNT"] != -1234.56 {
+		t.Fatalf("Unexpected record %v", m)
+	}
+
+	err := tbl.RecordAt(0, func(r *Record) {
+		v, err := r.Field("AMOUNT")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != NewDecimal(-123456, 2) {
+			t.Fatalf("Expected -1234.56, got %v", v)
+		}
+	}, ParseExactDecimals)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
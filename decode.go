@@ -0,0 +1,266 @@
+package dbf
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// structMapping maps the fields of a table to the fields of a struct type
+type structMapping struct {
+	fields []fieldMapping
+}
+
+type fieldMapping struct {
+	field *Field
+	// index is the path of the struct field, see reflect.Value.FieldByIndex
+	index []int
+	name  string
+}
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// mapping returns the mapping of struct type `t`, it is resolved once per table
+func (dbf *Dbf) mapping(t reflect.Type) (*structMapping, error) {
+	if m, ok := dbf.mappings.Load(t); ok {
+		return m.(*structMapping), nil
+	}
+	m := &structMapping{}
+	if err := dbf.mapFields(m, t, nil); err != nil {
+		return nil, err
+	}
+	dbf.mappings.Store(t, m)
+	return m, nil
+}
+
+// mapFields maps the exported fields of `t`.
+// Fields are matched by their `dbf` tag or their name, both case-insensitive. The tag "-" skips a field.
+// Fields with a tag must exist in the table, untagged fields without a matching column are skipped.
+func (dbf *Dbf) mapFields(m *structMapping, t reflect.Type, index []int) error {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, tagged := sf.Tag.Lookup("dbf")
+		if tag == "-" || (!sf.IsExported() && !sf.Anonymous) {
+			continue
+		}
+		path := append(append([]int{}, index...), i)
+		if sf.Anonymous && !tagged && sf.Type.Kind() == reflect.Struct {
+			if err := dbf.mapFields(m, sf.Type, path); err != nil {
+				return err
+			}
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		name := sf.Name
+		if tagged {
+			name = tag
+		}
+		f, err := dbf.FieldByName(name)
+		if err != nil {
+			if tagged {
+				return fmt.Errorf("Struct field %s. %w", sf.Name, err)
+			}
+			continue
+		}
+		m.fields = append(m.fields, fieldMapping{field: &dbf.fields[f.Index], index: path, name: sf.Name})
+	}
+	return nil
+}
+
+// Decode stores the fields of the record in the struct pointed to by `v`.
+// Struct fields are matched to table fields by their `dbf:"NAME"` tag or by their name, case-insensitive.
+// NULL values can be stored in pointers, interfaces and sql.Scanner implementations like sql.NullString.
+func (r *Record) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Decode: expected a pointer to a struct, got %T", v)
+	}
+	m, err := r.dbf.mapping(rv.Elem().Type())
+	if err != nil {
+		return err
+	}
+	return r.decode(m, rv.Elem())
+}
+
+func (r *Record) decode(m *structMapping, rv reflect.Value) error {
+	if err := r.parse(); err != nil {
+		return err
+	}
+	for _, fm := range m.fields {
+		var value interface{}
+		if !r.isNull(fm.field) {
+			v, ok, err := r.parseField(fm.field)
+			if err != nil {
+				return err
+			}
+			if ok {
+				value = v
+			}
+		}
+		if err := assign(rv.FieldByIndex(fm.index), value); err != nil {
+			return fmt.Errorf("Could not decode field %s into %s. %w", fm.field.Name, fm.name, err)
+		}
+	}
+	return nil
+}
+
+// ScanInto walks the entire table and decodes every record that is not deleted into a T, see Record.Decode.
+// It stops at the end or when walk returns a non nil error.
+func ScanInto[T any](dbf *Dbf, walk func(T) error, options ParseOption) error {
+	var zero T
+	rt := reflect.TypeOf(&zero).Elem()
+	if rt.Kind() != reflect.Struct {
+		return fmt.Errorf("ScanInto: expected a struct type, got %s", rt)
+	}
+	m, err := dbf.mapping(rt)
+	if err != nil {
+		return err
+	}
+	return dbf.Scan(func(r *Record) error {
+		if r.Deleted() {
+			return nil
+		}
+		v := zero
+		if err := r.decode(m, reflect.ValueOf(&v).Elem()); err != nil {
+			return err
+		}
+		return walk(v)
+	}, options)
+}
+
+var errNull = errors.New("NULL can only be stored in pointers, interfaces or sql.Scanner")
+
+// assign stores `v` in `dst`, converting between numeric types where no precision is lost
+func assign(dst reflect.Value, v interface{}) error {
+	if dst.CanAddr() && dst.Addr().Type().Implements(scannerType) {
+		return dst.Addr().Interface().(sql.Scanner).Scan(driverValue(v))
+	}
+	if v == nil {
+		switch dst.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		return errNull
+	}
+	if dst.Kind() == reflect.Pointer {
+		elem := reflect.New(dst.Type().Elem())
+		if err := assign(elem.Elem(), v); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	}
+
+	src := reflect.ValueOf(v)
+	if src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return nil
+	}
+	switch n := v.(type) {
+	case int32:
+		return assignInt(dst, int64(n), v)
+	case int64:
+		return assignInt(dst, n, v)
+	case float64:
+		return assignFloat(dst, n, v)
+	case Decimal:
+		if dst.Kind() == reflect.String {
+			dst.SetString(n.String())
+			return nil
+		}
+		if n.Scale() == 0 {
+			return assignInt(dst, n.Unscaled(), v)
+		}
+		return assignFloat(dst, n.Float64(), v)
+	case Currency:
+		if dst.Kind() == reflect.String {
+			dst.SetString(n.String())
+			return nil
+		}
+		if dst.Type() == reflect.TypeOf(Decimal{}) {
+			dst.Set(reflect.ValueOf(n.Decimal()))
+			return nil
+		}
+		return assignFloat(dst, n.Float64(), v)
+	case string:
+		if dst.Kind() == reflect.Slice && dst.Type().Elem().Kind() == reflect.Uint8 {
+			dst.SetBytes([]byte(n))
+			return nil
+		}
+		if dst.Kind() == reflect.String {
+			dst.SetString(n)
+			return nil
+		}
+	case []byte:
+		if dst.Kind() == reflect.String {
+			dst.SetString(string(n))
+			return nil
+		}
+	case bool:
+		if dst.Kind() == reflect.Bool {
+			dst.SetBool(n)
+			return nil
+		}
+	}
+	return mismatch(dst, v)
+}
+
+func assignInt(dst reflect.Value, n int64, v interface{}) error {
+	switch dst.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if dst.OverflowInt(n) {
+			return fmt.Errorf("Value %d overflows %s", n, dst.Type())
+		}
+		dst.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n < 0 || dst.OverflowUint(uint64(n)) {
+			return fmt.Errorf("Value %d overflows %s", n, dst.Type())
+		}
+		dst.SetUint(uint64(n))
+		return nil
+	case reflect.Float32, reflect.Float64:
+		dst.SetFloat(float64(n))
+		return nil
+	}
+	return mismatch(dst, v)
+}
+
+func assignFloat(dst reflect.Value, f float64, v interface{}) error {
+	switch dst.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if dst.OverflowFloat(f) {
+			return fmt.Errorf("Value %v overflows %s", f, dst.Type())
+		}
+		dst.SetFloat(f)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// Only whole numbers fit into integers
+		if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			return assignInt(dst, int64(f), v)
+		}
+	}
+	return mismatch(dst, v)
+}
+
+func mismatch(dst reflect.Value, v interface{}) error {
+	return fmt.Errorf("Cannot store %T (%v) in %s", v, v, dst.Type())
+}
+
+// driverValue converts a field value into one of the types that sql.Scanner implementations expect
+func driverValue(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int32:
+		return int64(n)
+	case Decimal:
+		return n.String()
+	case Currency:
+		return n.String()
+	}
+	return v
+}
@@ -0,0 +1,113 @@
+package dbf
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+type contact struct {
+	ID        int    `dbf:"contact_id"`
+	LastName  string `dbf:"last_name"`
+	Birthdate time.Time
+	Notes     *string
+	TypeID    uint8  `dbf:"CONTACT_TYPE_ID"`
+	Ignored   string `dbf:"-"`
+}
+
+func TestDecode(t *testing.T) {
+	tbl, err := Open("test/contacts.dbf", charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+	if err := tbl.ReadDBC(); err != nil {
+		t.Fatal(err)
+	}
+
+	var c contact
+	err = tbl.RecordAt(2, func(r *Record) {
+		err = r.Decode(&c)
+	}, ParseTrimRight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ID != 3 || c.LastName != "Fuller" || c.Birthdate.Year() != 1955 || c.Notes == nil || c.TypeID == 0 {
+		t.Errorf("Unexpected contact %+v", c)
+	}
+
+	var mismatch struct {
+		LastName int `dbf:"LAST_NAME"`
+	}
+	tbl.RecordAt(2, func(r *Record) {
+		err = r.Decode(&mismatch)
+	}, ParseTrimRight)
+	if err == nil || !strings.Contains(err.Error(), "LAST_NAME") || !strings.Contains(err.Error(), "int") {
+		t.Errorf("Expected a type mismatch error, got %v", err)
+	}
+
+	var missing struct {
+		Name string `dbf:"MISSING"`
+	}
+	tbl.RecordAt(2, func(r *Record) {
+		err = r.Decode(&missing)
+	}, ParseTrimRight)
+	if err == nil {
+		t.Errorf("Expected an error for a missing field")
+	}
+}
+
+func TestScanInto(t *testing.T) {
+	tbl := openTestTableReadWrite(t, createTestTable(t))
+	defer tbl.Close()
+	updated := time.Date(2023, 2, 25, 13, 45, 10, 0, time.Local)
+	records := []map[string]interface{}{
+		{"ID": 1, "NAME": "First", "AMOUNT": 12.5, "UPDATED": updated, "NICK": "one"},
+		{"ID": 2, "NAME": "Deleted"},
+		{"ID": 3, "NAME": "Third", "AMOUNT": 3, "UPDATED": nil, "NICK": nil},
+	}
+	for _, r := range records {
+		if _, err := tbl.Append(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tbl.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+
+	type row struct {
+		ID      int32
+		Name    string
+		Amount  Decimal
+		Updated *time.Time
+		Nick    sql.NullString
+	}
+	var rows []row
+	err := ScanInto(tbl, func(r row) error {
+		rows = append(rows, r)
+		return nil
+	}, ParseTrimRight|ParseExactDecimals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %v", rows)
+	}
+	if rows[0].ID != 1 || rows[0].Name != "First" || rows[0].Amount.String() != "12.50" || rows[0].Updated == nil || !rows[0].Updated.Equal(updated) || rows[0].Nick.String != "one" {
+		t.Errorf("Unexpected row %+v", rows[0])
+	}
+	if rows[1].ID != 3 || rows[1].Updated != nil || rows[1].Nick.Valid {
+		t.Errorf("Unexpected row %+v", rows[1])
+	}
+
+	type strict struct {
+		Nick string
+	}
+	err = ScanInto(tbl, func(strict) error { return nil }, 0)
+	if err == nil || !strings.Contains(err.Error(), "NICK") {
+		t.Errorf("Expected an error for NULL in a string, got %v", err)
+	}
+}
@@ -0,0 +1,455 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Kirides/go-dbf"
+)
+
+// value is one of string, float64, bool, date, time.Time or nil (NULL)
+type value = interface{}
+
+// date is a date without time portion, the zero value is an empty date
+type date struct {
+	time.Time
+}
+
+var errTypeMismatch = errors.New("Operator/operand type mismatch")
+
+type node interface {
+	eval(r *dbf.Record) (value, error)
+	typ() rune
+}
+
+// kind maps field types to the basic expression types
+func kind(t rune) rune {
+	switch t {
+	case 'C', 'V', 'M':
+		return 'C'
+	case 'N', 'F', 'I', 'B', 'Y':
+		return 'N'
+	}
+	return t
+}
+
+type literalNode struct {
+	v value
+	t rune
+}
+
+func (n *literalNode) eval(r *dbf.Record) (value, error) { return n.v, nil }
+func (n *literalNode) typ() rune                         { return n.t }
+
+type fieldNode struct {
+	f dbf.Field
+}
+
+func (n *fieldNode) typ() rune { return n.f.Type }
+
+func (n *fieldNode) eval(r *dbf.Record) (value, error) {
+	if r == nil {
+		return nil, fmt.Errorf("No record to read field %s from", n.f.Name)
+	}
+	v, err := r.FieldAt(n.f.Index)
+	if err != nil {
+		return nil, err
+	}
+	return fieldValue(&n.f, v)
+}
+
+// fieldValue converts a decoded field value into an expression value
+func fieldValue(f *dbf.Field, v interface{}) (value, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		// keys and comparisons rely on the fixed width of character fields
+		if f.Type == 'C' {
+			if n := int(f.Length) - utf8.RuneCountInString(v); n > 0 {
+				return v + strings.Repeat(" ", n), nil
+			}
+		}
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case bool:
+		return v, nil
+	case time.Time:
+		if v.Equal(dbf.MinimumDateTime()) {
+			v = time.Time{}
+		}
+		if f.Type == 'D' {
+			return date{v}, nil
+		}
+		return v, nil
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case dbf.Decimal:
+		return v.Float64(), nil
+	case dbf.Currency:
+		return v.Float64(), nil
+	}
+	return nil, fmt.Errorf("Field %s of type %c is not supported in expressions", f.Name, f.Type)
+}
+
+type notNode struct {
+	x node
+}
+
+func (n *notNode) typ() rune { return 'L' }
+
+func (n *notNode) eval(r *dbf.Record) (value, error) {
+	v, err := n.x.eval(r)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return nil, errTypeMismatch
+	}
+	return !b, nil
+}
+
+type negateNode struct {
+	x node
+}
+
+func (n *negateNode) typ() rune { return 'N' }
+
+func (n *negateNode) eval(r *dbf.Record) (value, error) {
+	v, err := n.x.eval(r)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return nil, errTypeMismatch
+	}
+	return -f, nil
+}
+
+// logicalNode implements .AND. and .OR. with short-circuit evaluation
+type logicalNode struct {
+	or   bool
+	l, r node
+}
+
+func (n *logicalNode) typ() rune { return 'L' }
+
+func (n *logicalNode) eval(r *dbf.Record) (value, error) {
+	l, err := n.l.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	lb, ok := l.(bool)
+	if !ok && l != nil {
+		return nil, errTypeMismatch
+	}
+	if l != nil && lb == n.or {
+		return lb, nil
+	}
+	rv, err := n.r.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	rb, ok := rv.(bool)
+	if !ok && rv != nil {
+		return nil, errTypeMismatch
+	}
+	if rv != nil && rb == n.or {
+		return rb, nil
+	}
+	if l == nil || rv == nil {
+		return nil, nil
+	}
+	return rb, nil
+}
+
+type binaryNode struct {
+	op   string
+	l, r node
+}
+
+func (n *binaryNode) typ() rune {
+	switch n.op {
+	case "+", "-":
+		lt, rt := kind(n.l.typ()), kind(n.r.typ())
+		switch {
+		case lt == 'C':
+			return 'C'
+		case (lt == 'D' || lt == 'T') && lt == rt:
+			return 'N'
+		case lt == 'D' || lt == 'T':
+			return lt
+		case rt == 'D' || rt == 'T':
+			return rt
+		}
+		return 'N'
+	case "*", "/", "%", "^":
+		return 'N'
+	}
+	return 'L'
+}
+
+func (n *binaryNode) eval(r *dbf.Record) (value, error) {
+	l, err := n.l.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	rv, err := n.r.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	if l == nil || rv == nil {
+		return nil, nil
+	}
+	switch n.op {
+	case "+":
+		return add(l, rv)
+	case "-":
+		return subtract(l, rv)
+	case "*", "/", "%", "^":
+		return arithmetic(n.op, l, rv)
+	case "$":
+		ls, lok := l.(string)
+		rs, rok := rv.(string)
+		if !lok || !rok {
+			return nil, errTypeMismatch
+		}
+		return strings.Contains(rs, ls), nil
+	case "==":
+		c, err := compare(l, rv, true)
+		return c == 0, err
+	}
+	c, err := compare(l, rv, false)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "=":
+		return c == 0, nil
+	case "!=", "<>", "#":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case ">":
+		return c > 0, nil
+	case "<=":
+		return c <= 0, nil
+	}
+	return c >= 0, nil
+}
+
+func add(l, r value) (value, error) {
+	switch l := l.(type) {
+	case string:
+		if r, ok := r.(string); ok {
+			return l + r, nil
+		}
+	case float64:
+		switch r := r.(type) {
+		case float64:
+			return l + r, nil
+		case date, time.Time:
+			return add(r, l)
+		}
+	case date:
+		if r, ok := r.(float64); ok {
+			return date{l.AddDate(0, 0, int(r))}, nil
+		}
+	case time.Time:
+		if r, ok := r.(float64); ok {
+			return l.Add(time.Duration(r * float64(time.Second))), nil
+		}
+	}
+	return nil, errTypeMismatch
+}
+
+func subtract(l, r value) (value, error) {
+	switch l := l.(type) {
+	case string:
+		// trailing blanks of the left operand are moved to the end of the result
+		if r, ok := r.(string); ok {
+			trimmed := strings.TrimRight(l, " ")
+			return trimmed + r + l[len(trimmed):], nil
+		}
+	case float64:
+		if r, ok := r.(float64); ok {
+			return l - r, nil
+		}
+	case date:
+		switch r := r.(type) {
+		case float64:
+			return date{l.AddDate(0, 0, -int(r))}, nil
+		case date:
+			return math.Round(l.Sub(r.Time).Hours() / 24), nil
+		}
+	case time.Time:
+		switch r := r.(type) {
+		case float64:
+			return l.Add(-time.Duration(r * float64(time.Second))), nil
+		case time.Time:
+			return l.Sub(r).Seconds(), nil
+		}
+	}
+	return nil, errTypeMismatch
+}
+
+func arithmetic(op string, l, r value) (value, error) {
+	a, aok := l.(float64)
+	b, bok := r.(float64)
+	if !aok || !bok {
+		return nil, errTypeMismatch
+	}
+	switch op {
+	case "*":
+		return a * b, nil
+	case "/":
+		if b == 0 {
+			return nil, errors.New("Division by 0")
+		}
+		return a / b, nil
+	case "%":
+		return mod(a, b)
+	}
+	return math.Pow(a, b), nil
+}
+
+// mod returns the remainder with the sign of the divisor like MOD()
+func mod(a, b float64) (value, error) {
+	if b == 0 {
+		return nil, errors.New("Division by 0")
+	}
+	m := math.Mod(a, b)
+	if m != 0 && (m < 0) != (b < 0) {
+		m += b
+	}
+	return m, nil
+}
+
+// compare compares two values of the same type.
+// Unless exact is set, strings compare like SET EXACT OFF:
+// the comparison stops at the end of the right operand.
+func compare(l, r value, exact bool) (int, error) {
+	switch l := l.(type) {
+	case string:
+		r, ok := r.(string)
+		if !ok {
+			return 0, errTypeMismatch
+		}
+		if exact {
+			return strings.Compare(l, r), nil
+		}
+		return compareStrings(l, r), nil
+	case float64:
+		r, ok := r.(float64)
+		if !ok {
+			return 0, errTypeMismatch
+		}
+		switch {
+		case l < r:
+			return -1, nil
+		case l > r:
+			return 1, nil
+		}
+		return 0, nil
+	case bool:
+		r, ok := r.(bool)
+		if !ok {
+			return 0, errTypeMismatch
+		}
+		switch {
+		case l == r:
+			return 0, nil
+		case r:
+			return -1, nil
+		}
+		return 1, nil
+	case date:
+		switch r := r.(type) {
+		case date:
+			return l.Compare(r.Time), nil
+		case time.Time:
+			return l.Compare(r), nil
+		}
+	case time.Time:
+		switch r := r.(type) {
+		case date:
+			return l.Compare(r.Time), nil
+		case time.Time:
+			return l.Compare(r), nil
+		}
+	}
+	return 0, errTypeMismatch
+}
+
+func compareStrings(l, r string) int {
+	lr, rr := []rune(l), []rune(r)
+	if len(lr) > len(rr) {
+		lr = lr[:len(rr)]
+	}
+	for i := range rr {
+		lc := ' '
+		if i < len(lr) {
+			lc = lr[i]
+		}
+		switch {
+		case lc < rr[i]:
+			return -1
+		case lc > rr[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+type callNode struct {
+	fn   *function
+	args []node
+}
+
+func (n *callNode) typ() rune {
+	if n.fn.typ != nil {
+		return n.fn.typ(n.args)
+	}
+	return n.fn.result
+}
+
+func (n *callNode) eval(r *dbf.Record) (value, error) {
+	var v value
+	var err error
+	if n.fn.lazy != nil {
+		v, err = n.fn.lazy(r, n.args)
+	} else {
+		v, err = n.call(r)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", n.fn.name, err)
+	}
+	return v, nil
+}
+
+func (n *callNode) call(r *dbf.Record) (value, error) {
+	var buf [4]value
+	args := buf[:0]
+	for _, a := range n.args {
+		v, err := a.eval(r)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil && !n.fn.nulls {
+			return nil, nil
+		}
+		args = append(args, v)
+	}
+	return n.fn.call(r, args)
+}
@@ -0,0 +1,100 @@
+// Package expr parses and evaluates xBase (FoxPro) expressions as they are
+// stored in index keys, FOR clauses and DBC field rules and defaults.
+//
+// Values are represented as string, float64, bool and time.Time. NULL is nil.
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kirides/go-dbf"
+)
+
+// Expr is a compiled expression
+type Expr struct {
+	src  string
+	root node
+}
+
+// Compile parses src and resolves its field references against tbl.
+// tbl may be nil for expressions that do not reference any field.
+func Compile(src string, tbl *dbf.Dbf) (*Expr, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := parser{tokens: tokens, tbl: tbl}
+	root, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse %q: %w", src, err)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// MustCompile is like Compile but panics if the expression is invalid
+func MustCompile(src string, tbl *dbf.Dbf) *Expr {
+	e, err := Compile(src, tbl)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// String returns the source of the expression
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Type returns the xBase type of the result: 'C', 'N', 'D', 'T' or 'L'.
+// A bare field reference returns the type of that field, 'U' means unknown.
+func (e *Expr) Type() rune {
+	return e.root.typ()
+}
+
+// Eval evaluates the expression against r.
+// r may be nil if the expression does not reference any field.
+func (e *Expr) Eval(r *dbf.Record) (interface{}, error) {
+	v, err := e.root.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	switch v := v.(type) {
+	case date:
+		if v.IsZero() {
+			return dbf.MinimumDateTime(), nil
+		}
+		return v.Time, nil
+	case time.Time:
+		if v.IsZero() {
+			return dbf.MinimumDateTime(), nil
+		}
+	}
+	return v, nil
+}
+
+// Bool evaluates a logical expression, like a FOR clause or a field rule.
+// NULL evaluates to false.
+func (e *Expr) Bool(r *dbf.Record) (bool, error) {
+	v, err := e.root.eval(r)
+	if err != nil {
+		return false, err
+	}
+	switch v := v.(type) {
+	case bool:
+		return v, nil
+	case nil:
+		return false, nil
+	}
+	return false, fmt.Errorf("Expression %s is not logical", strings.TrimSpace(e.src))
+}
+
+// Eval compiles and evaluates src against r in one step
+func Eval(src string, tbl *dbf.Dbf, r *dbf.Record) (interface{}, error) {
+	e, err := Compile(src, tbl)
+	if err != nil {
+		return nil, err
+	}
+	return e.Eval(r)
+}
@@ -0,0 +1,173 @@
+package expr
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Kirides/go-dbf"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestEvalConstant(t *testing.T) {
+	tests := []struct {
+		src  string
+		want interface{}
+	}{
+		{`UPPER("abc") + 'def'`, "ABCdef"},
+		{`"abc   " - "def"`, "abcdef   "},
+		{`ALLTRIM("  x  ")`, "x"},
+		{`PADL("7", 3, "0")`, "007"},
+		{`PADR(12, 4)`, "12  "},
+		{`STR(3.14159, 6, 2)`, "  3.14"},
+		{`STR(-42)`, "       -42"},
+		{`STR(123456, 3)`, "***"},
+		{`SUBSTR("abcdef", 2, 3)`, "bcd"},
+		{`LEFT("abc", 2) + RIGHT("abc", 1)`, "abc"},
+		{`AT("c", "abcabc", 2)`, float64(6)},
+		{`VAL(" 12.5abc") * 2`, float64(25)},
+		{`DTOS({^2020-02-29} + 1)`, "20200301"},
+		{`DTOC({^2021-12-24})`, "12/24/21"},
+		{`TTOC({^2021-12-24 13:05:09}, 1)`, "20211224130509"},
+		{`YEAR(GOMONTH({^2020-01-31}, 13)) * 100 + DAY(GOMONTH({^2020-01-31}, 13))`, float64(202128)},
+		{`{^2021-01-10} - {^2021-01-01}`, float64(9)},
+		{`IIF(1 > 2, "yes", "no")`, "no"},
+		{`"abc" = "ab" .AND. !("abc" == "ab")`, true},
+		{`"ab" $ "xaby" AND NOT .F.`, true},
+		{`INLIST(3, 1, 2, 3) .OR. 1/0 = 1`, true},
+		{`BETWEEN(5, 1, 10) .AND. EMPTY("   ") .AND. EMPTY({})`, true},
+		{`NVL(.NULL., 7) + ROUND(2.5, 0) + MOD(-7, 3) + 2^3 ** 1`, float64(20)},
+		{`ISNULL(UPPER(NULL))`, true},
+		{`MAX(3, 9, 4) - MIN(3, 9, 4)`, float64(6)},
+		{`ALLT(STRT("a-b-c", "-", ""))`, "abc"},
+		{`REPLICATE("ab", 3) + SPACE(2) + PADC("x", 4, "ü")`, "ababab  üxüü"},
+	}
+	for _, tt := range tests {
+		got, err := Eval(tt.src, nil, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.src, err)
+		}
+		if got != tt.want {
+			t.Fatalf("%s: expected %#v, got %#v", tt.src, tt.want, got)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, src := range []string{`UPPER("a"`, `FOO(1)`, `1 +`, `"abc`, `{^2021-02-30}`, `SUBSTR("a")`, `unknown_field`} {
+		if _, err := Compile(src, nil); err == nil {
+			t.Fatalf("Expected an error for %s", src)
+		}
+	}
+	if _, err := Eval(`"a" + 1`, nil, nil); err == nil {
+		t.Fatalf("Expected a type mismatch")
+	}
+	for _, src := range []string{`REPLICATE("x", 2000000000)`, `SPACE(2 * 10^9)`, `PADL("x", 2000000000)`, `STR(1, 2000000000)`} {
+		if _, err := Eval(src, nil, nil); !errors.Is(err, errStringTooLong) {
+			t.Fatalf("%s: expected errStringTooLong, got %v", src, err)
+		}
+	}
+	for _, src := range []string{`LEFT("abc", 10^20)`, `RIGHT("abc", -10^20)`, `STR(5, 10^20)`, `GOMONTH({^2020-01-31}, 10^15)`, `SPACE(10^400)`, `SUBSTR("abc", 10^400 - 10^400)`} {
+		if _, err := Eval(src, nil, nil); !errors.Is(err, errArgument) {
+			t.Fatalf("%s: expected errArgument, got %v", src, err)
+		}
+	}
+}
+
+func TestEvalDate(t *testing.T) {
+	v, err := Eval(`CTOD("12/24/2021")`, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.(time.Time).Equal(time.Date(2021, 12, 24, 0, 0, 0, 0, time.Local)) {
+		t.Fatalf("Unexpected date %v", v)
+	}
+	if v, _ := Eval(`{}`, nil, nil); v != dbf.MinimumDateTime() {
+		t.Fatalf("Expected the minimum date for an empty date, got %v", v)
+	}
+}
+
+// TestIndexKeys reproduces every key of the contacts.cdx tags from its expression
+func TestIndexKeys(t *testing.T) {
+	tbl, err := dbf.Open(`../test/contacts.dbf`, charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+
+	idx, err := tbl.Indexes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range idx.Tags() {
+		e, err := Compile(tag.KeyExpr, tbl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tag.SetKeyType(e.Type())
+		n := 0
+		err = tag.Walk(func(key []byte, recno uint32) error {
+			n++
+			return tbl.RecordAt(recno, func(r *dbf.Record) {
+				v, err := e.Eval(r)
+				if err != nil {
+					t.Fatalf("%s: %v", tag.Name, err)
+				}
+				want, err := tag.EncodeKey(v)
+				if err != nil {
+					t.Fatalf("%s: %v", tag.Name, err)
+				}
+				if !bytes.Equal(bytes.TrimRight(want, " \x00"), bytes.TrimRight(key, " \x00")) {
+					t.Fatalf("%s: key for record %d is %q, expression gives %q", tag.Name, recno, key, want)
+				}
+			}, 0)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n == 0 {
+			t.Fatalf("%s: no keys", tag.Name)
+		}
+	}
+}
+
+// TestForClause evaluates the FOR clause of the DBC indexes
+func TestForClause(t *testing.T) {
+	tbl, err := dbf.Open(`../test/contacts.dbc`, charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+
+	idx, err := tbl.Indexes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, err := idx.Tag("OBJECTNAME")
+	if err != nil {
+		t.Fatal(err)
+	}
+	forExpr, err := Compile(tag.ForExpr, tbl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexed := map[uint32]bool{}
+	tag.Walk(func(key []byte, recno uint32) error {
+		indexed[recno] = true
+		return nil
+	})
+	err = tbl.Scan(func(r *dbf.Record) error {
+		ok, err := forExpr.Bool(r)
+		if err != nil {
+			return err
+		}
+		if ok != indexed[r.Recno()] {
+			t.Fatalf("Record %d: FOR clause is %v but indexed is %v", r.Recno(), ok, indexed[r.Recno()])
+		}
+		return nil
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,1040 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Kirides/go-dbf"
+)
+
+type function struct {
+	name    string
+	minArgs int
+	maxArgs int // -1 means unlimited
+	result  rune
+	// nulls is set for functions that handle NULL arguments themselves,
+	// all others return NULL as soon as one argument is NULL
+	nulls bool
+	call  func(r *dbf.Record, args []value) (value, error)
+	// lazy functions evaluate their arguments themselves
+	lazy func(r *dbf.Record, args []node) (value, error)
+	// typ overrides result for functions returning one of their arguments
+	typ func(args []node) rune
+}
+
+var functions = map[string]*function{}
+
+func register(fns ...*function) {
+	for _, fn := range fns {
+		functions[fn.name] = fn
+	}
+}
+
+// lookupFunction finds a function by name.
+// Like FoxPro, names can be abbreviated to at least four characters.
+func lookupFunction(name string) (*function, error) {
+	name = strings.ToUpper(name)
+	if fn, ok := functions[name]; ok {
+		return fn, nil
+	}
+	if len(name) >= 4 {
+		var matches []string
+		for n := range functions {
+			if strings.HasPrefix(n, name) {
+				matches = append(matches, n)
+			}
+		}
+		if len(matches) == 1 {
+			return functions[matches[0]], nil
+		}
+		if len(matches) > 1 {
+			sort.Strings(matches)
+			return nil, fmt.Errorf("Ambiguous function %s (%s)", name, strings.Join(matches, ", "))
+		}
+	}
+	return nil, fmt.Errorf("Unknown function %s", name)
+}
+
+var errArgument = errors.New("Function argument value, type, or count is invalid")
+
+// maxStringLength is the longest string Visual FoxPro can hold
+const maxStringLength = 16777184
+
+var errStringTooLong = errors.New("String is too long")
+
+// repeat returns `s` repeated `n` times, or an error if the result would exceed maxStringLength
+func repeat(s string, n int) (string, error) {
+	if n <= 0 {
+		return "", nil
+	}
+	if len(s) > 0 && n > maxStringLength/len(s) {
+		return "", errStringTooLong
+	}
+	return strings.Repeat(s, n), nil
+}
+
+func argString(v value) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	return "", errArgument
+}
+
+func argNumber(v value) (float64, error) {
+	if f, ok := v.(float64); ok {
+		return f, nil
+	}
+	return 0, errArgument
+}
+
+// argInt accepts whole numbers in the range of a 32 bit integer, fractions are truncated
+func argInt(v value) (int, error) {
+	f, err := argNumber(v)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(f) || f < math.MinInt32 || f > math.MaxInt32 {
+		return 0, errArgument
+	}
+	return int(f), nil
+}
+
+// argTime accepts dates and datetimes
+func argTime(v value) (time.Time, error) {
+	switch v := v.(type) {
+	case date:
+		return v.Time, nil
+	case time.Time:
+		return v, nil
+	}
+	return time.Time{}, errArgument
+}
+
+func argType(args []node, i int) rune {
+	if i < len(args) {
+		return kind(args[i].typ())
+	}
+	return 'U'
+}
+
+func strings1(name string, f func(string) string) *function {
+	return &function{name: name, minArgs: 1, maxArgs: 1, result: 'C', call: func(r *dbf.Record, args []value) (value, error) {
+		s, err := argString(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return f(s), nil
+	}}
+}
+
+func dateParts(name string, f func(time.Time) int) *function {
+	return &function{name: name, minArgs: 1, maxArgs: 1, result: 'N', call: func(r *dbf.Record, args []value) (value, error) {
+		t, err := argTime(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if t.IsZero() {
+			return float64(0), nil
+		}
+		return float64(f(t)), nil
+	}}
+}
+
+func numbers1(name string, f func(float64) float64) *function {
+	return &function{name: name, minArgs: 1, maxArgs: 1, result: 'N', call: func(r *dbf.Record, args []value) (value, error) {
+		n, err := argNumber(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return f(n), nil
+	}}
+}
+
+func isClass(name string, f func(rune) bool) *function {
+	return &function{name: name, minArgs: 1, maxArgs: 1, result: 'L', call: func(r *dbf.Record, args []value) (value, error) {
+		s, err := argString(args[0])
+		if err != nil {
+			return nil, err
+		}
+		c, _ := utf8.DecodeRuneInString(s)
+		return s != "" && f(c), nil
+	}}
+}
+
+func init() {
+	register(
+		// strings
+		strings1("UPPER", strings.ToUpper),
+		strings1("LOWER", strings.ToLower),
+		strings1("PROPER", proper),
+		strings1("ALLTRIM", func(s string) string { return strings.Trim(s, " ") }),
+		strings1("LTRIM", func(s string) string { return strings.TrimLeft(s, " ") }),
+		strings1("RTRIM", func(s string) string { return strings.TrimRight(s, " ") }),
+		strings1("TRIM", func(s string) string { return strings.TrimRight(s, " ") }),
+		isClass("ISALPHA", unicode.IsLetter),
+		isClass("ISDIGIT", unicode.IsDigit),
+		isClass("ISUPPER", unicode.IsUpper),
+		isClass("ISLOWER", unicode.IsLower),
+		&function{name: "LEN", minArgs: 1, maxArgs: 1, result: 'N', call: fnLen},
+		&function{name: "LEFT", minArgs: 2, maxArgs: 2, result: 'C', call: fnLeft},
+		&function{name: "RIGHT", minArgs: 2, maxArgs: 2, result: 'C', call: fnRight},
+		&function{name: "SUBSTR", minArgs: 2, maxArgs: 3, result: 'C', call: fnSubstr},
+		&function{name: "AT", minArgs: 2, maxArgs: 3, result: 'N', call: fnAt(false)},
+		&function{name: "ATC", minArgs: 2, maxArgs: 3, result: 'N', call: fnAt(true)},
+		&function{name: "RAT", minArgs: 2, maxArgs: 3, result: 'N', call: fnRat},
+		&function{name: "OCCURS", minArgs: 2, maxArgs: 2, result: 'N', call: fnOccurs},
+		&function{name: "STRTRAN", minArgs: 2, maxArgs: 4, result: 'C', call: fnStrtran},
+		&function{name: "CHRTRAN", minArgs: 3, maxArgs: 3, result: 'C', call: fnChrtran},
+		&function{name: "STUFF", minArgs: 4, maxArgs: 4, result: 'C', call: fnStuff},
+		&function{name: "SPACE", minArgs: 1, maxArgs: 1, result: 'C', call: fnSpace},
+		&function{name: "REPLICATE", minArgs: 2, maxArgs: 2, result: 'C', call: fnReplicate},
+		&function{name: "PADL", minArgs: 2, maxArgs: 3, result: 'C', call: fnPad('L')},
+		&function{name: "PADR", minArgs: 2, maxArgs: 3, result: 'C', call: fnPad('R')},
+		&function{name: "PADC", minArgs: 2, maxArgs: 3, result: 'C', call: fnPad('C')},
+		&function{name: "CHR", minArgs: 1, maxArgs: 1, result: 'C', call: fnChr},
+		&function{name: "ASC", minArgs: 1, maxArgs: 1, result: 'N', call: fnAsc},
+		&function{name: "STR", minArgs: 1, maxArgs: 3, result: 'C', call: fnStr},
+		&function{name: "VAL", minArgs: 1, maxArgs: 1, result: 'N', call: fnVal},
+		&function{name: "TRANSFORM", minArgs: 1, maxArgs: 2, result: 'C', call: fnTransform},
+		&function{name: "BINTOC", minArgs: 1, maxArgs: 2, result: 'C', call: fnBintoc},
+
+		// dates
+		&function{name: "DATE", minArgs: 0, maxArgs: 3, result: 'D', call: fnDate},
+		&function{name: "DATETIME", minArgs: 0, maxArgs: 6, result: 'T', call: fnDatetime},
+		&function{name: "DTOS", minArgs: 1, maxArgs: 1, result: 'C', call: fnDtos},
+		&function{name: "DTOC", minArgs: 1, maxArgs: 2, result: 'C', call: fnDtoc},
+		&function{name: "CTOD", minArgs: 1, maxArgs: 1, result: 'D', call: fnCtod},
+		&function{name: "TTOC", minArgs: 1, maxArgs: 2, result: 'C', call: fnTtoc},
+		&function{name: "TTOD", minArgs: 1, maxArgs: 1, result: 'D', call: fnTtod},
+		&function{name: "DTOT", minArgs: 1, maxArgs: 1, result: 'T', call: fnDtot},
+		&function{name: "GOMONTH", minArgs: 2, maxArgs: 2, result: 'D', call: fnGomonth},
+		&function{name: "CDOW", minArgs: 1, maxArgs: 1, result: 'C', call: fnCdow},
+		&function{name: "CMONTH", minArgs: 1, maxArgs: 1, result: 'C', call: fnCmonth},
+		dateParts("YEAR", time.Time.Year),
+		dateParts("MONTH", func(t time.Time) int { return int(t.Month()) }),
+		dateParts("DAY", time.Time.Day),
+		dateParts("DOW", func(t time.Time) int { return int(t.Weekday()) + 1 }),
+		dateParts("HOUR", time.Time.Hour),
+		dateParts("MINUTE", time.Time.Minute),
+		dateParts("SEC", time.Time.Second),
+
+		// numbers
+		numbers1("INT", math.Trunc),
+		numbers1("ABS", math.Abs),
+		numbers1("CEILING", math.Ceil),
+		numbers1("FLOOR", math.Floor),
+		numbers1("SQRT", math.Sqrt),
+		numbers1("SIGN", func(f float64) float64 {
+			switch {
+			case f < 0:
+				return -1
+			case f > 0:
+				return 1
+			}
+			return 0
+		}),
+		&function{name: "ROUND", minArgs: 2, maxArgs: 2, result: 'N', call: fnRound},
+		&function{name: "MOD", minArgs: 2, maxArgs: 2, result: 'N', call: fnMod},
+		&function{name: "MAX", minArgs: 2, maxArgs: -1, typ: firstArgType, call: fnMinMax(1)},
+		&function{name: "MIN", minArgs: 2, maxArgs: -1, typ: firstArgType, call: fnMinMax(-1)},
+		&function{name: "BETWEEN", minArgs: 3, maxArgs: 3, result: 'L', call: fnBetween},
+		&function{name: "INLIST", minArgs: 2, maxArgs: -1, result: 'L', call: fnInlist},
+
+		// logical and general
+		&function{name: "IIF", minArgs: 3, maxArgs: 3, lazy: fnIif, typ: func(args []node) rune { return argType(args, 1) }},
+		&function{name: "EMPTY", minArgs: 1, maxArgs: 1, result: 'L', nulls: true, call: fnEmpty},
+		&function{name: "ISBLANK", minArgs: 1, maxArgs: 1, result: 'L', nulls: true, call: fnEmpty},
+		&function{name: "ISNULL", minArgs: 1, maxArgs: 1, result: 'L', nulls: true, call: fnIsnull},
+		&function{name: "NVL", minArgs: 2, maxArgs: 2, nulls: true, typ: firstArgType, call: fnNvl},
+		&function{name: "EVL", minArgs: 2, maxArgs: 2, nulls: true, typ: firstArgType, call: fnEvl},
+		&function{name: "DELETED", minArgs: 0, maxArgs: 1, result: 'L', call: fnDeleted},
+		&function{name: "RECNO", minArgs: 0, maxArgs: 1, result: 'N', call: fnRecno},
+	)
+}
+
+func firstArgType(args []node) rune {
+	return argType(args, 0)
+}
+
+func proper(s string) string {
+	rs := []rune(strings.ToLower(s))
+	start := true
+	for i, c := range rs {
+		if start && unicode.IsLetter(c) {
+			rs[i] = unicode.ToUpper(c)
+		}
+		start = c == ' '
+	}
+	return string(rs)
+}
+
+func fnLen(r *dbf.Record, args []value) (value, error) {
+	s, err := argString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return float64(utf8.RuneCountInString(s)), nil
+}
+
+func fnLeft(r *dbf.Record, args []value) (value, error) {
+	s, err := argString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	n, err := argInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	rs := []rune(s)
+	n = clamp(n, 0, len(rs))
+	return string(rs[:n]), nil
+}
+
+func fnRight(r *dbf.Record, args []value) (value, error) {
+	s, err := argString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	n, err := argInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	rs := []rune(s)
+	n = clamp(n, 0, len(rs))
+	return string(rs[len(rs)-n:]), nil
+}
+
+func fnSubstr(r *dbf.Record, args []value) (value, error) {
+	s, err := argString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	start, err := argInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	rs := []rune(s)
+	if start < 1 || start > len(rs) {
+		return "", nil
+	}
+	end := len(rs)
+	if len(args) > 2 {
+		n, err := argInt(args[2])
+		if err != nil {
+			return nil, err
+		}
+		end = clamp(start-1+n, start-1, len(rs))
+	}
+	return string(rs[start-1 : end]), nil
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+func occurrence(args []value) (int, error) {
+	if len(args) < 3 {
+		return 1, nil
+	}
+	return argInt(args[2])
+}
+
+// fnAt returns the 1-based position of the nth occurrence of a string
+func fnAt(ignoreCase bool) func(r *dbf.Record, args []value) (value, error) {
+	return func(r *dbf.Record, args []value) (value, error) {
+		needle, err := argString(args[0])
+		if err != nil {
+			return nil, err
+		}
+		hay, err := argString(args[1])
+		if err != nil {
+			return nil, err
+		}
+		n, err := occurrence(args)
+		if err != nil {
+			return nil, err
+		}
+		if ignoreCase {
+			needle, hay = strings.ToUpper(needle), strings.ToUpper(hay)
+		}
+		if needle == "" || n < 1 {
+			return float64(0), nil
+		}
+		offset := 0
+		for {
+			i := strings.Index(hay[offset:], needle)
+			if i < 0 {
+				return float64(0), nil
+			}
+			n--
+			if n == 0 {
+				return float64(utf8.RuneCountInString(hay[:offset+i]) + 1), nil
+			}
+			offset += i + len(needle)
+		}
+	}
+}
+
+func fnRat(r *dbf.Record, args []value) (value, error) {
+	needle, err := argString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	hay, err := argString(args[1])
+	if err != nil {
+		return nil, err
+	}
+	n, err := occurrence(args)
+	if err != nil {
+		return nil, err
+	}
+	if needle == "" || n < 1 {
+		return float64(0), nil
+	}
+	for {
+		i := strings.LastIndex(hay, needle)
+		if i < 0 {
+			return float64(0), nil
+		}
+		n--
+		if n == 0 {
+			return float64(utf8.RuneCountInString(hay[:i]) + 1), nil
+		}
+		hay = hay[:i]
+	}
+}
+
+func fnOccurs(r *dbf.Record, args []value) (value, error) {
+	needle, err := argString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	hay, err := argString(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if needle == "" {
+		return float64(0), nil
+	}
+	return float64(strings.Count(hay, needle)), nil
+}
+
+func fnStrtran(r *dbf.Record, args []value) (value, error) {
+	s, err := argString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	old, err := argString(args[1])
+	if err != nil {
+		return nil, err
+	}
+	replacement := ""
+	if len(args) > 2 {
+		if replacement, err = argString(args[2]); err != nil {
+			return nil, err
+		}
+	}
+	if len(args) < 4 {
+		return strings.ReplaceAll(s, old, replacement), nil
+	}
+	// replace starting at the nth occurrence
+	n, err := argInt(args[3])
+	if err != nil {
+		return nil, err
+	}
+	if old == "" || n < 1 {
+		return s, nil
+	}
+	offset := 0
+	for n > 1 {
+		i := strings.Index(s[offset:], old)
+		if i < 0 {
+			return s, nil
+		}
+		offset += i + len(old)
+		n--
+	}
+	return s[:offset] + strings.ReplaceAll(s[offset:], old, replacement), nil
+}
+
+func fnChrtran(r *dbf.Record, args []value) (value, error) {
+	s, err := argString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	from, err := argString(args[1])
+	if err != nil {
+		return nil, err
+	}
+	to, err := argString(args[2])
+	if err != nil {
+		return nil, err
+	}
+	fr, tr := []rune(from), []rune(to)
+	var sb strings.Builder
+	for _, c := range s {
+		i := strings.IndexRune(from, c)
+		if i < 0 {
+			sb.WriteRune(c)
+			continue
+		}
+		i = utf8.RuneCountInString(from[:i])
+		if i < len(tr) && i < len(fr) {
+			sb.WriteRune(tr[i])
+		}
+	}
+	return sb.String(), nil
+}
+
+func fnStuff(r *dbf.Record, args []value) (value, error) {
+	s, err := argString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	start, err := argInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	n, err := argInt(args[2])
+	if err != nil {
+		return nil, err
+	}
+	insert, err := argString(args[3])
+	if err != nil {
+		return nil, err
+	}
+	rs := []rune(s)
+	start = clamp(start-1, 0, len(rs))
+	end := clamp(start+n, start, len(rs))
+	return string(rs[:start]) + insert + string(rs[end:]), nil
+}
+
+func fnSpace(r *dbf.Record, args []value) (value, error) {
+	n, err := argInt(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return repeat(" ", n)
+}
+
+func fnReplicate(r *dbf.Record, args []value) (value, error) {
+	s, err := argString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	n, err := argInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return repeat(s, n)
+}
+
+// fnPad pads to the left, right or center. Non character values are converted like TRANSFORM.
+func fnPad(side byte) func(r *dbf.Record, args []value) (value, error) {
+	return func(r *dbf.Record, args []value) (value, error) {
+		s := transform(args[0])
+		n, err := argInt(args[1])
+		if err != nil {
+			return nil, err
+		}
+		fill := " "
+		if len(args) > 2 {
+			if fill, err = argString(args[2]); err != nil {
+				return nil, err
+			}
+			if fill == "" {
+				fill = " "
+			}
+			fill = string([]rune(fill)[:1])
+		}
+		rs := []rune(s)
+		if n <= len(rs) {
+			return string(rs[:clamp(n, 0, len(rs))]), nil
+		}
+		missing := n - len(rs)
+		padding, err := repeat(fill, missing)
+		if err != nil {
+			return nil, err
+		}
+		switch side {
+		case 'L':
+			return padding + s, nil
+		case 'R':
+			return s + padding, nil
+		}
+		left := len(padding) / len(fill) / 2 * len(fill)
+		return padding[:left] + s + padding[left:], nil
+	}
+}
+
+func fnChr(r *dbf.Record, args []value) (value, error) {
+	n, err := argInt(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n > 255 {
+		return nil, errArgument
+	}
+	return string(rune(n)), nil
+}
+
+func fnAsc(r *dbf.Record, args []value) (value, error) {
+	s, err := argString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if s == "" {
+		return float64(0), nil
+	}
+	c, _ := utf8.DecodeRuneInString(s)
+	return float64(c), nil
+}
+
+func fnStr(r *dbf.Record, args []value) (value, error) {
+	n, err := argNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+	length, decimals := 10, 0
+	if len(args) > 1 {
+		if length, err = argInt(args[1]); err != nil {
+			return nil, err
+		}
+	}
+	if len(args) > 2 {
+		if decimals, err = argInt(args[2]); err != nil {
+			return nil, err
+		}
+	}
+	if length > maxStringLength {
+		return nil, errStringTooLong
+	}
+	return formatStr(n, length, decimals), nil
+}
+
+// formatStr right aligns n in length characters like STR().
+// Decimals are dropped if the value does not fit, asterisks are returned if it still overflows.
+func formatStr(n float64, length, decimals int) string {
+	if length <= 0 {
+		return ""
+	}
+	// more decimals than float64 digits only add zeros
+	decimals = clamp(decimals, 0, 18)
+	for d := decimals; d >= 0; d-- {
+		s := strconv.FormatFloat(round(n, d), 'f', d, 64)
+		if len(s) <= length {
+			return strings.Repeat(" ", length-len(s)) + s
+		}
+	}
+	return strings.Repeat("*", length)
+}
+
+// round rounds half away from zero
+func round(n float64, decimals int) float64 {
+	p := math.Pow10(decimals)
+	return math.Round(n*p) / p
+}
+
+func fnVal(r *dbf.Record, args []value) (value, error) {
+	s, err := argString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	s = strings.TrimLeft(s, " ")
+	end := 0
+	for end < len(s) {
+		c := s[end]
+		if (c >= '0' && c <= '9') || c == '.' || ((c == '-' || c == '+') && end == 0) {
+			end++
+			continue
+		}
+		break
+	}
+	for end > 0 {
+		if v, err := strconv.ParseFloat(s[:end], 64); err == nil {
+			return v, nil
+		}
+		end--
+	}
+	return float64(0), nil
+}
+
+// transform converts any value to its default character representation
+func transform(v value) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return ".T."
+		}
+		return ".F."
+	case date:
+		return dtoc(v.Time)
+	case time.Time:
+		return ttoc(v)
+	}
+	return ".NULL."
+}
+
+func fnTransform(r *dbf.Record, args []value) (value, error) {
+	s := transform(args[0])
+	if len(args) > 1 {
+		picture, err := argString(args[1])
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(picture, "@") && strings.ContainsRune(picture, '!') {
+			s = strings.ToUpper(s)
+		}
+	}
+	return s, nil
+}
+
+// fnBintoc returns the sortable binary representation of an integer used in index keys
+func fnBintoc(r *dbf.Record, args []value) (value, error) {
+	n, err := argNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+	size := 4
+	if len(args) > 1 {
+		if size, err = argInt(args[1]); err != nil {
+			return nil, err
+		}
+	}
+	var u uint64
+	switch size {
+	case 1:
+		u = uint64(uint8(int8(n)) ^ 0x80)
+	case 2:
+		u = uint64(uint16(int16(n)) ^ 0x8000)
+	case 4:
+		u = uint64(uint32(int32(n)) ^ 0x80000000)
+	default:
+		return nil, errArgument
+	}
+	b := make([]rune, size)
+	for i := size - 1; i >= 0; i-- {
+		b[i] = rune(u & 0xFF)
+		u >>= 8
+	}
+	return string(b), nil
+}
+
+func today() time.Time {
+	y, m, d := time.Now().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+func fnDate(r *dbf.Record, args []value) (value, error) {
+	if len(args) == 0 {
+		return date{today()}, nil
+	}
+	if len(args) != 3 {
+		return nil, errArgument
+	}
+	var ymd [3]int
+	for i := range ymd {
+		n, err := argInt(args[i])
+		if err != nil {
+			return nil, err
+		}
+		ymd[i] = n
+	}
+	d, ok := makeDate(ymd[0], ymd[1], ymd[2])
+	if !ok {
+		return nil, errArgument
+	}
+	return date{d}, nil
+}
+
+func fnDatetime(r *dbf.Record, args []value) (value, error) {
+	if len(args) == 0 {
+		return time.Now().Truncate(time.Second), nil
+	}
+	if len(args) < 3 {
+		return nil, errArgument
+	}
+	var parts [6]int
+	for i, a := range args {
+		n, err := argInt(a)
+		if err != nil {
+			return nil, err
+		}
+		parts[i] = n
+	}
+	d, ok := makeDate(parts[0], parts[1], parts[2])
+	if !ok {
+		return nil, errArgument
+	}
+	return d.Add(time.Duration(parts[3])*time.Hour + time.Duration(parts[4])*time.Minute + time.Duration(parts[5])*time.Second), nil
+}
+
+func fnDtos(r *dbf.Record, args []value) (value, error) {
+	t, err := argTime(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if t.IsZero() {
+		return "        ", nil
+	}
+	return t.Format("20060102"), nil
+}
+
+func dtoc(t time.Time) string {
+	if t.IsZero() {
+		return "  /  /  "
+	}
+	return t.Format("01/02/06")
+}
+
+func fnDtoc(r *dbf.Record, args []value) (value, error) {
+	t, err := argTime(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > 1 {
+		return fnDtos(r, args[:1])
+	}
+	return dtoc(t), nil
+}
+
+func fnCtod(r *dbf.Record, args []value) (value, error) {
+	s, err := argString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	s = strings.TrimSpace(s)
+	var d time.Time
+	var ok bool
+	if strings.HasPrefix(s, "^") {
+		d, ok = parseYMD(strings.TrimPrefix(s, "^"))
+	} else {
+		d, ok = parseMDY(s)
+	}
+	if !ok {
+		return date{}, nil
+	}
+	return date{d}, nil
+}
+
+func ttoc(t time.Time) string {
+	if t.IsZero() {
+		return "  /  /     :  :  "
+	}
+	return t.Format("01/02/06 03:04:05 PM")
+}
+
+func fnTtoc(r *dbf.Record, args []value) (value, error) {
+	t, err := argTime(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > 1 {
+		if t.IsZero() {
+			return strings.Repeat(" ", 14), nil
+		}
+		return t.Format("20060102150405"), nil
+	}
+	return ttoc(t), nil
+}
+
+func fnTtod(r *dbf.Record, args []value) (value, error) {
+	t, err := argTime(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if t.IsZero() {
+		return date{}, nil
+	}
+	y, m, d := t.Date()
+	return date{time.Date(y, m, d, 0, 0, 0, 0, time.Local)}, nil
+}
+
+func fnDtot(r *dbf.Record, args []value) (value, error) {
+	t, err := argTime(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func fnGomonth(r *dbf.Record, args []value) (value, error) {
+	t, err := argTime(args[0])
+	if err != nil {
+		return nil, err
+	}
+	n, err := argInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if t.IsZero() {
+		return date{}, nil
+	}
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(n), 1, 0, 0, 0, 0, time.Local)
+	last := first.AddDate(0, 1, -1).Day()
+	return date{first.AddDate(0, 0, clamp(d, 1, last)-1)}, nil
+}
+
+func fnCdow(r *dbf.Record, args []value) (value, error) {
+	t, err := argTime(args[0])
+	if err != nil || t.IsZero() {
+		return "", err
+	}
+	return t.Weekday().String(), nil
+}
+
+func fnCmonth(r *dbf.Record, args []value) (value, error) {
+	t, err := argTime(args[0])
+	if err != nil || t.IsZero() {
+		return "", err
+	}
+	return t.Month().String(), nil
+}
+
+func fnRound(r *dbf.Record, args []value) (value, error) {
+	n, err := argNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+	d, err := argInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return round(n, d), nil
+}
+
+func fnMod(r *dbf.Record, args []value) (value, error) {
+	a, err := argNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+	b, err := argNumber(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return mod(a, b)
+}
+
+func fnMinMax(sign int) func(r *dbf.Record, args []value) (value, error) {
+	return func(r *dbf.Record, args []value) (value, error) {
+		best := args[0]
+		for _, v := range args[1:] {
+			c, err := compare(v, best, true)
+			if err != nil {
+				return nil, err
+			}
+			if c*sign > 0 {
+				best = v
+			}
+		}
+		return best, nil
+	}
+}
+
+func fnBetween(r *dbf.Record, args []value) (value, error) {
+	lo, err := compare(args[0], args[1], false)
+	if err != nil {
+		return nil, err
+	}
+	hi, err := compare(args[0], args[2], false)
+	if err != nil {
+		return nil, err
+	}
+	return lo >= 0 && hi <= 0, nil
+}
+
+func fnInlist(r *dbf.Record, args []value) (value, error) {
+	for _, v := range args[1:] {
+		c, err := compare(args[0], v, false)
+		if err != nil {
+			return nil, err
+		}
+		if c == 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func fnIif(r *dbf.Record, args []node) (value, error) {
+	cond, err := args[0].eval(r)
+	if err != nil {
+		return nil, err
+	}
+	if b, ok := cond.(bool); ok && b {
+		return args[1].eval(r)
+	} else if !ok && cond != nil {
+		return nil, errArgument
+	}
+	return args[2].eval(r)
+}
+
+func isEmpty(v value) bool {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimLeft(v, " \t\r\n") == ""
+	case float64:
+		return v == 0
+	case bool:
+		return !v
+	case date:
+		return v.IsZero()
+	case time.Time:
+		return v.IsZero()
+	}
+	return false
+}
+
+func fnEmpty(r *dbf.Record, args []value) (value, error) {
+	return isEmpty(args[0]), nil
+}
+
+func fnIsnull(r *dbf.Record, args []value) (value, error) {
+	return args[0] == nil, nil
+}
+
+func fnNvl(r *dbf.Record, args []value) (value, error) {
+	if args[0] == nil {
+		return args[1], nil
+	}
+	return args[0], nil
+}
+
+func fnEvl(r *dbf.Record, args []value) (value, error) {
+	if args[0] == nil || isEmpty(args[0]) {
+		return args[1], nil
+	}
+	return args[0], nil
+}
+
+func fnDeleted(r *dbf.Record, args []value) (value, error) {
+	if r == nil {
+		return nil, errors.New("No record")
+	}
+	return r.Deleted(), nil
+}
+
+func fnRecno(r *dbf.Record, args []value) (value, error) {
+	if r == nil {
+		return nil, errors.New("No record")
+	}
+	return float64(r.Recno() + 1), nil
+}
@@ -0,0 +1,161 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokDate
+	tokIdent
+	tokTrue
+	tokFalse
+	tokNull
+	tokAnd
+	tokOr
+	tokNot
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+	tokDot
+	tokArrow
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// dotted keywords like .T. or .AND.
+var dottedKeywords = map[string]tokenKind{
+	"T":    tokTrue,
+	"Y":    tokTrue,
+	"F":    tokFalse,
+	"N":    tokFalse,
+	"NULL": tokNull,
+	"AND":  tokAnd,
+	"OR":   tokOr,
+	"NOT":  tokNot,
+}
+
+var operators = []string{"**", "==", "!=", "<>", "<=", ">=", "+", "-", "*", "/", "%", "^", "=", "#", "<", ">", "$"}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	rs := []rune(src)
+	i := 0
+	for i < len(rs) {
+		c := rs[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || (c == '.' && i+1 < len(rs) && unicode.IsDigit(rs[i+1])):
+			start := i
+			for i < len(rs) && (unicode.IsDigit(rs[i]) || rs[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: string(rs[start:i]), pos: start})
+		case c == '.':
+			end := i + 1
+			for end < len(rs) && unicode.IsLetter(rs[end]) {
+				end++
+			}
+			if end < len(rs) && rs[end] == '.' {
+				if kind, ok := dottedKeywords[strings.ToUpper(string(rs[i+1:end]))]; ok {
+					tokens = append(tokens, token{kind: kind, text: string(rs[i : end+1]), pos: i})
+					i = end + 1
+					continue
+				}
+			}
+			tokens = append(tokens, token{kind: tokDot, text: ".", pos: i})
+			i++
+		case c == '"' || c == '\'' || c == '[':
+			end := c
+			if c == '[' {
+				end = ']'
+			}
+			start := i
+			i++
+			for i < len(rs) && rs[i] != end {
+				i++
+			}
+			if i >= len(rs) {
+				return nil, fmt.Errorf("Unterminated string at position %d", start)
+			}
+			tokens = append(tokens, token{kind: tokString, text: string(rs[start+1 : i]), pos: start})
+			i++
+		case c == '{':
+			start := i
+			for i < len(rs) && rs[i] != '}' {
+				i++
+			}
+			if i >= len(rs) {
+				return nil, fmt.Errorf("Unterminated date at position %d", start)
+			}
+			tokens = append(tokens, token{kind: tokDate, text: string(rs[start+1 : i]), pos: start})
+			i++
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(rs) && (unicode.IsLetter(rs[i]) || unicode.IsDigit(rs[i]) || rs[i] == '_') {
+				i++
+			}
+			text := string(rs[start:i])
+			kind := tokIdent
+			switch strings.ToUpper(text) {
+			case "AND":
+				kind = tokAnd
+			case "OR":
+				kind = tokOr
+			case "NOT":
+				kind = tokNot
+			case "NULL":
+				kind = tokNull
+			}
+			tokens = append(tokens, token{kind: kind, text: text, pos: start})
+		case c == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{kind: tokComma, text: ",", pos: i})
+			i++
+		case c == '-' && i+1 < len(rs) && rs[i+1] == '>':
+			tokens = append(tokens, token{kind: tokArrow, text: "->", pos: i})
+			i += 2
+		case c == '!' && (i+1 >= len(rs) || rs[i+1] != '='):
+			tokens = append(tokens, token{kind: tokNot, text: "!", pos: i})
+			i++
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(string(rs[i:]), o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("Unexpected character %q at position %d", c, i)
+			}
+			tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+			i += len([]rune(op))
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(rs)}), nil
+}
@@ -0,0 +1,390 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kirides/go-dbf"
+)
+
+type parser struct {
+	tokens []token
+	pos    int
+	tbl    *dbf.Dbf
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(kind tokenKind, what string) error {
+	if t := p.next(); t.kind != kind {
+		return fmt.Errorf("Expected %s but got %s at position %d", what, t, t.pos)
+	}
+	return nil
+}
+
+func (p *parser) isOp(ops ...string) bool {
+	t := p.peek()
+	if t.kind != tokOp {
+		return false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) parse() (node, error) {
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("Unexpected %s at position %d", t, t.pos)
+	}
+	return n, nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = &logicalNode{or: true, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	l, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd {
+		p.next()
+		r, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l = &logicalNode{l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.peek().kind == tokNot {
+		p.next()
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{x: x}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	l, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("=", "==", "!=", "<>", "#", "<", ">", "<=", ">=", "$") {
+		op := p.next().text
+		r, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryNode{op: op, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	l, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("+", "-") {
+		op := p.next().text
+		r, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryNode{op: op, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseMultiplicative() (node, error) {
+	l, err := p.parsePower()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("*", "/", "%") {
+		op := p.next().text
+		r, err := p.parsePower()
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryNode{op: op, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parsePower() (node, error) {
+	l, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("^", "**") {
+		p.next()
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryNode{op: "^", l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.isOp("-", "+") {
+		op := p.next().text
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if op == "+" {
+			return x, nil
+		}
+		return &negateNode{x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		if strings.Count(t.text, ".") > 1 {
+			return nil, fmt.Errorf("Invalid number %s at position %d", t.text, t.pos)
+		}
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid number %s at position %d", t.text, t.pos)
+		}
+		return &literalNode{v: v, t: 'N'}, nil
+	case tokString:
+		return &literalNode{v: t.text, t: 'C'}, nil
+	case tokTrue:
+		return &literalNode{v: true, t: 'L'}, nil
+	case tokFalse:
+		return &literalNode{v: false, t: 'L'}, nil
+	case tokNull:
+		return &literalNode{v: nil, t: 'U'}, nil
+	case tokDate:
+		v, err := parseDateLiteral(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("%w at position %d", err, t.pos)
+		}
+		if _, ok := v.(date); ok {
+			return &literalNode{v: v, t: 'D'}, nil
+		}
+		return &literalNode{v: v, t: 'T'}, nil
+	case tokLParen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case tokIdent:
+		if p.peek().kind == tokLParen {
+			return p.parseCall(t)
+		}
+		// alias.field and alias->field, the alias is ignored
+		if k := p.peek().kind; k == tokDot || k == tokArrow {
+			p.next()
+			name := p.next()
+			if name.kind != tokIdent {
+				return nil, fmt.Errorf("Expected field name but got %s at position %d", name, name.pos)
+			}
+			t = name
+		}
+		return p.field(t)
+	}
+	return nil, fmt.Errorf("Unexpected %s at position %d", t, t.pos)
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	fn, err := lookupFunction(name.text)
+	if err != nil {
+		return nil, fmt.Errorf("%w at position %d", err, name.pos)
+	}
+	p.next() // (
+	var args []node
+	if p.peek().kind != tokRParen {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.peek().kind != tokComma {
+				break
+			}
+			p.next()
+		}
+	}
+	if err := p.expect(tokRParen, "')'"); err != nil {
+		return nil, err
+	}
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return nil, fmt.Errorf("Invalid argument count for %s() at position %d", fn.name, name.pos)
+	}
+	return &callNode{fn: fn, args: args}, nil
+}
+
+func (p *parser) field(t token) (node, error) {
+	if p.tbl == nil {
+		return nil, fmt.Errorf("Unknown field %s at position %d", t.text, t.pos)
+	}
+	f, err := p.tbl.FieldByName(t.text)
+	if err != nil && len(t.text) > 10 {
+		// field names are truncated to 10 characters in free tables
+		f, err = p.tbl.FieldByName(t.text[:10])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unknown field %s at position %d", t.text, t.pos)
+	}
+	return &fieldNode{f: f}, nil
+}
+
+// parseDateLiteral parses the contents of {^yyyy-mm-dd [hh:mm[:ss]]}, {mm/dd/yyyy} or {}
+func parseDateLiteral(s string) (value, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "/" || s == "//" {
+		return date{}, nil
+	}
+	if s == "/:" || s == "//:" {
+		return time.Time{}, nil
+	}
+	strict := strings.HasPrefix(s, "^")
+	s = strings.TrimSpace(strings.TrimPrefix(s, "^"))
+	datePart, timePart, hasTime := strings.Cut(s, " ")
+	if !hasTime {
+		datePart, timePart, hasTime = strings.Cut(s, ",")
+	}
+	var d time.Time
+	var ok bool
+	if strict {
+		d, ok = parseYMD(datePart)
+	} else {
+		d, ok = parseMDY(datePart)
+	}
+	if !ok {
+		return nil, fmt.Errorf("Invalid date {%s}", s)
+	}
+	if !hasTime {
+		return date{d}, nil
+	}
+	t, ok := parseClock(strings.TrimSpace(timePart))
+	if !ok {
+		return nil, fmt.Errorf("Invalid datetime {%s}", s)
+	}
+	return d.Add(t), nil
+}
+
+func splitDate(s string) []int {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == '-' || r == '/' || r == '.' })
+	if len(parts) != 3 {
+		return nil
+	}
+	n := make([]int, 3)
+	for i, part := range parts {
+		v, err := strconv.Atoi(part)
+		if err != nil {
+			return nil
+		}
+		n[i] = v
+	}
+	return n
+}
+
+func parseYMD(s string) (time.Time, bool) {
+	n := splitDate(s)
+	if n == nil {
+		return time.Time{}, false
+	}
+	return makeDate(n[0], n[1], n[2])
+}
+
+func parseMDY(s string) (time.Time, bool) {
+	n := splitDate(s)
+	if n == nil {
+		return time.Time{}, false
+	}
+	year := n[2]
+	if year < 100 {
+		year += 1900
+	}
+	return makeDate(year, n[0], n[1])
+}
+
+func makeDate(year, month, day int) (time.Time, bool) {
+	d := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Local)
+	if d.Year() != year || int(d.Month()) != month || d.Day() != day {
+		return time.Time{}, false
+	}
+	return d, true
+}
+
+// parseClock parses hh[:mm[:ss]] with an optional AM/PM suffix
+func parseClock(s string) (time.Duration, bool) {
+	upper := strings.ToUpper(s)
+	pm := strings.HasSuffix(upper, "PM") || strings.HasSuffix(upper, "P")
+	am := strings.HasSuffix(upper, "AM") || strings.HasSuffix(upper, "A")
+	s = strings.TrimSpace(strings.TrimRight(upper, "APM"))
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, false
+	}
+	var hms [3]int
+	for i, part := range parts {
+		v, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, false
+		}
+		hms[i] = v
+	}
+	if pm && hms[0] < 12 {
+		hms[0] += 12
+	} else if am && hms[0] == 12 {
+		hms[0] = 0
+	}
+	if hms[0] > 23 || hms[1] > 59 || hms[2] > 59 {
+		return 0, false
+	}
+	return time.Duration(hms[0])*time.Hour + time.Duration(hms[1])*time.Minute + time.Duration(hms[2])*time.Second, true
+}
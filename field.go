@@ -3,6 +3,7 @@ package dbf
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"io"
 
 	"golang.org/x/text/encoding"
@@ -36,16 +37,25 @@ type Field struct {
 	NullFieldIndex     int
 }
 
-func readFields(r io.ReadSeeker, decoder *encoding.Decoder) ([]Field, error) {
+// readFields reads the field descriptors following the header.
+// Only Visual FoxPro stores displacements and field flags, older dialects
+// get their displacements computed from the field lengths.
+func readFields(r io.ReadSeeker, decoder *encoding.Decoder, h *Header) ([]Field, error) {
 	var fields []Field
-	if _, err := r.Seek(32, io.SeekStart); err != nil {
+	start, size := 32, 32
+	if h.Type.isDBase7() {
+		start, size = dBase7HeaderSize, dBase7FieldSize
+	}
+	if _, err := r.Seek(int64(start), io.SeekStart); err != nil {
 		return nil, err
 	}
-	buf := make([]byte, 32, 32)
+	buf := make([]byte, size)
 
+	vfp := h.Type.isVisualFoxPro()
 	index := 0
 	nullFieldIndex := -1
-	for {
+	displacement := uint32(1)
+	for offset := start; offset+size <= int(h.HeaderSize); offset += size {
 		if _, err := readAll(r, buf); err != nil {
 			return nil, err
 		}
@@ -54,27 +64,46 @@ func readFields(r io.ReadSeeker, decoder *encoding.Decoder) ([]Field, error) {
 		}
 		f := Field{}
 
-		f.Name, _ = decoder.String(string(buf[:bytes.IndexByte(buf, 0x00)]))
-		f.Type = rune(buf[11])
-		f.Displacement = binary.LittleEndian.Uint32(buf[12:])
-		f.Length = buf[16]
-		f.DecimalCount = buf[17]
-		f.Flags = FieldFlag(buf[18])
-		f.NextAutoIncrement = binary.LittleEndian.Uint32(buf[19:])
-		f.AutoIncrementStep = buf[23]
 		f.Index = index
-
 		f.VarLengthSizeIndex = -1
 		f.NullFieldIndex = -1
 
-		if f.Type == 'V' || f.Type == 'Q' {
-			nullFieldIndex++
-			f.VarLengthSizeIndex = nullFieldIndex
+		if size == dBase7FieldSize {
+			f.Name, _ = decoder.String(string(trimName(buf[:32])))
+			f.Type = rune(buf[32])
+			f.Length = buf[33]
+			f.DecimalCount = buf[34]
+			f.NextAutoIncrement = binary.LittleEndian.Uint32(buf[40:])
+			f.Displacement = displacement
+			displacement += uint32(f.Length)
+			index++
+			fields = append(fields, f)
+			continue
 		}
-		if (f.Flags & FieldFlagNull) != 0 {
-			nullFieldIndex++
-			f.NullFieldIndex = nullFieldIndex
+
+		f.Name, _ = decoder.String(string(trimName(buf[:11])))
+		f.Type = rune(buf[11])
+		f.Length = buf[16]
+		f.DecimalCount = buf[17]
+
+		if vfp {
+			f.Displacement = binary.LittleEndian.Uint32(buf[12:])
+			f.Flags = FieldFlag(buf[18])
+			f.NextAutoIncrement = binary.LittleEndian.Uint32(buf[19:])
+			f.AutoIncrementStep = buf[23]
+
+			if f.Type == 'V' || f.Type == 'Q' {
+				nullFieldIndex++
+				f.VarLengthSizeIndex = nullFieldIndex
+			}
+			if (f.Flags & FieldFlagNull) != 0 {
+				nullFieldIndex++
+				f.NullFieldIndex = nullFieldIndex
+			}
+		} else {
+			f.Displacement = displacement
 		}
+		displacement += uint32(f.Length)
 
 		index++
 
@@ -83,3 +112,40 @@ func readFields(r io.ReadSeeker, decoder *encoding.Decoder) ([]Field, error) {
 
 	return fields, nil
 }
+
+// trimName cuts a field name at its terminating 0x00
+func trimName(name []byte) []byte {
+	if i := bytes.IndexByte(name, 0x00); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
+
+func writeFields(w io.Writer, fields []Field, encoder *encoding.Encoder) error {
+	buf := make([]byte, 32, 32)
+	for _, f := range fields {
+		for i := range buf {
+			buf[i] = 0x00
+		}
+		name, err := encoder.String(f.Name)
+		if err != nil {
+			return fmt.Errorf("Could not encode field name %q. %w", f.Name, err)
+		}
+		if len(name) == 0 || len(name) > 10 {
+			return fmt.Errorf("Invalid field name %q", f.Name)
+		}
+		copy(buf, name)
+		buf[11] = byte(f.Type)
+		binary.LittleEndian.PutUint32(buf[12:], f.Displacement)
+		buf[16] = f.Length
+		buf[17] = f.DecimalCount
+		buf[18] = byte(f.Flags)
+		binary.LittleEndian.PutUint32(buf[19:], f.NextAutoIncrement)
+		buf[23] = f.AutoIncrementStep
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write([]byte{fieldDescriptorTerminator})
+	return err
+}
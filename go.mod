@@ -4,6 +4,5 @@ go 1.20
 
 require (
 	go4.org v0.0.0-20230225012048-214862532bf5
-	golang.org/x/exp v0.0.0-20230224173230-c95f2b4c22f2
 	golang.org/x/text v0.7.0
 )
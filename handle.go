@@ -0,0 +1,219 @@
+package dbf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/transform"
+)
+
+// FieldHandle is a field of a table that has been looked up once, see Dbf.FieldHandle.
+// The typed getters of Record read the field without looking it up and without boxing the value.
+type FieldHandle struct {
+	dbf   *Dbf
+	field *Field
+}
+
+// FieldHandle returns the handle of the field `name` (Case insensitive)
+func (dbf *Dbf) FieldHandle(name string) (FieldHandle, error) {
+	i := dbf.fieldIndex(name)
+	if i < 0 {
+		return FieldHandle{}, fmt.Errorf("Field not found %q", name)
+	}
+	return FieldHandle{dbf: dbf, field: &dbf.fields[i]}, nil
+}
+
+// Field returns the field of the handle
+func (h FieldHandle) Field() Field {
+	if h.field == nil {
+		return Field{}
+	}
+	return *h.field
+}
+
+// errFieldHandle is returned for handles of other tables or the zero handle
+var errFieldHandle = errors.New("Field handle does not belong to this table")
+
+// handleField returns the field of `h` and makes sure the record is read
+func (r *Record) handleField(h FieldHandle) (*Field, error) {
+	if h.dbf != r.dbf || h.field == nil {
+		return nil, errFieldHandle
+	}
+	if err := r.parse(); err != nil {
+		return nil, err
+	}
+	return h.field, nil
+}
+
+// fieldTypeError is returned when a getter does not support the type of a field
+func fieldTypeError(f *Field, getter string) error {
+	return fmt.Errorf("Field %s of type %c can not be read as %s", f.Name, f.Type, getter)
+}
+
+// IsNull reports if the field is NULL
+func (r *Record) IsNull(h FieldHandle) (bool, error) {
+	f, err := r.handleField(h)
+	if err != nil {
+		return false, err
+	}
+	return r.isNull(f), nil
+}
+
+// Int64 returns the value of an `I`, `+` or `N`/`F` field without decimals. NULL is 0.
+func (r *Record) Int64(h FieldHandle) (int64, error) {
+	f, err := r.handleField(h)
+	if err != nil || r.isNull(f) {
+		return 0, err
+	}
+	switch f.Type {
+	case 'I', '+':
+		if r.dbf.header.Type.isDBase7() {
+			return int64(dBase7Int(r.data[f.Displacement:])), nil
+		}
+		return int64(int32(binary.LittleEndian.Uint32(r.data[f.Displacement:]))), nil
+	case 'N', 'F':
+		if f.DecimalCount == 0 {
+			v, err := parseIntBytes(bytes.TrimSpace(r.data[f.Displacement : f.Displacement+uint32(f.Length)]))
+			if err != nil {
+				return 0, fmt.Errorf("Could not parse field %s. %w", f.Name, err)
+			}
+			return v, nil
+		}
+	}
+	return 0, fieldTypeError(f, "int64")
+}
+
+// Float64 returns the value of a numeric field. NULL is 0.
+func (r *Record) Float64(h FieldHandle) (float64, error) {
+	f, err := r.handleField(h)
+	if err != nil || r.isNull(f) {
+		return 0, err
+	}
+	switch f.Type {
+	case 'I', '+':
+		v, err := r.Int64(h)
+		return float64(v), err
+	case 'N', 'F':
+		v, err := parseFloatBytes(bytes.TrimSpace(r.data[f.Displacement : f.Displacement+uint32(f.Length)]))
+		if err != nil {
+			return 0, fmt.Errorf("Could not parse field %s. %w", f.Name, err)
+		}
+		return v, nil
+	case 'Y':
+		return Currency(binary.LittleEndian.Uint64(r.data[f.Displacement:])).Float64(), nil
+	case 'O':
+		return dBase7Double(r.data[f.Displacement:]), nil
+	case 'B':
+		if f.Length != 10 {
+			return math.Float64frombits(binary.LittleEndian.Uint64(r.data[f.Displacement:])), nil
+		}
+	}
+	return 0, fieldTypeError(f, "float64")
+}
+
+// Bool returns the value of an `L` field. NULL is false.
+func (r *Record) Bool(h FieldHandle) (bool, error) {
+	f, err := r.handleField(h)
+	if err != nil || r.isNull(f) {
+		return false, err
+	}
+	if f.Type != 'L' {
+		return false, fieldTypeError(f, "bool")
+	}
+	v := r.data[f.Displacement]
+	return v != 32 && v > 0, nil
+}
+
+// Time returns the value of a `D`, `T` or `@` field in the local timezone. NULL is the zero time.
+func (r *Record) Time(h FieldHandle) (time.Time, error) {
+	f, err := r.handleField(h)
+	if err != nil || r.isNull(f) {
+		return time.Time{}, err
+	}
+	switch f.Type {
+	case 'D':
+		v, err := parseDateBytesYYYYMMDD(r.data[f.Displacement : f.Displacement+uint32(f.Length)])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("Could not parse field %s. %w", f.Name, err)
+		}
+		return v, nil
+	case 'T':
+		return julianDateTimeToTime(binary.LittleEndian.Uint64(r.data[f.Displacement:])), nil
+	case '@':
+		return julianDateTimeToTime(dBase7Timestamp(r.data[f.Displacement : f.Displacement+8])), nil
+	}
+	return time.Time{}, fieldTypeError(f, "time.Time")
+}
+
+// String returns the decoded value of a `C`, `V` or `M` field. NULL is "".
+func (r *Record) String(h FieldHandle) (string, error) {
+	buf := getBuffer(0)
+	b, err := r.AppendString(buf[:0], h)
+	s := string(b)
+	// AppendString may have grown b into a new array, only the pooled buffer goes back
+	putBuffer(buf)
+	return s, err
+}
+
+// AppendString appends the decoded value of a `C`, `V` or `M` field to dst and returns the extended buffer.
+// Fields with the binary flag are appended without decoding. NULL appends nothing.
+func (r *Record) AppendString(dst []byte, h FieldHandle) ([]byte, error) {
+	f, err := r.handleField(h)
+	if err != nil || r.isNull(f) {
+		return dst, err
+	}
+	var src []byte
+	switch f.Type {
+	case 'C':
+		src = r.data[f.Displacement : f.Displacement+uint32(f.Length)]
+	case 'V':
+		src = r.data[f.Displacement : f.Displacement+uint32(r.varLength(f))]
+	case 'M':
+		memo, buf, err := r.readMemo(f)
+		if err != nil {
+			return dst, err
+		}
+		if (f.Flags & FieldFlagBinary) != 0 {
+			dst = append(dst, memo...)
+		} else {
+			dst = r.appendDecoded(dst, memo)
+		}
+		if buf != nil {
+			putBuffer(buf)
+		}
+		return dst, nil
+	default:
+		return dst, fieldTypeError(f, "string")
+	}
+	if (f.Flags & FieldFlagBinary) != 0 {
+		return append(dst, src...), nil
+	}
+	n := len(dst)
+	dst = r.appendDecoded(dst, src)
+	if (r.parseOptions & ParseTrimRight) != 0 {
+		dst = dst[:n+len(bytes.TrimRight(dst[n:], " "))]
+	}
+	return dst, nil
+}
+
+// appendDecoded appends src, decoded with the table's decoder, to dst
+func (r *Record) appendDecoded(dst, src []byte) []byte {
+	if cap(dst)-len(dst) < len(src) {
+		dst = append(dst, make([]byte, len(src))...)[:len(dst)]
+	}
+	for {
+		n := len(dst)
+		nDst, nSrc, err := r.dbf.decoder.Transform(dst[n:cap(dst)], src, true)
+		dst = dst[:n+nDst]
+		src = src[nSrc:]
+		if err != transform.ErrShortDst {
+			return dst
+		}
+		dst = append(dst, make([]byte, len(src)+utf8.UTFMax)...)[:len(dst)]
+	}
+}
@@ -0,0 +1,181 @@
+package dbf
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestFieldHandles(t *testing.T) {
+	path := createTestTable(t)
+	tbl := openTestTableReadWrite(t, path)
+	birthday := time.Date(1980, 5, 17, 0, 0, 0, 0, time.Local)
+	_, err := tbl.Append(map[string]interface{}{
+		"ID":       42,
+		"NAME":     "Müller",
+		"AMOUNT":   1234.5,
+		"ACTIVE":   true,
+		"BIRTHDAY": birthday,
+		"UPDATED":  nil,
+		"NOTES":    "Some längere memo",
+		"NICK":     "mü",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbl.Close()
+
+	tbl, err = Open(path, charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+
+	handle := func(name string) FieldHandle {
+		h, err := tbl.FieldHandle(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	id, name, amount, active, bday, updated, notes, nick := handle("id"), handle("Name"), handle("AMOUNT"),
+		handle("active"), handle("birthday"), handle("updated"), handle("notes"), handle("nick")
+	if _, err := tbl.FieldHandle("MISSING"); err == nil {
+		t.Errorf("Expected an error for an unknown field")
+	}
+
+	err = tbl.RecordAt(0, func(r *Record) {
+		if v, err := r.Int64(id); err != nil || v != 42 {
+			t.Errorf("Int64: %v %v", v, err)
+		}
+		if v, err := r.Float64(amount); err != nil || v != 1234.5 {
+			t.Errorf("Float64: %v %v", v, err)
+		}
+		if v, err := r.Float64(id); err != nil || v != 42 {
+			t.Errorf("Float64 of an integer: %v %v", v, err)
+		}
+		if v, err := r.String(name); err != nil || v != "Müller" {
+			t.Errorf("String: %q %v", v, err)
+		}
+		if v, err := r.String(nick); err != nil || v != "mü" {
+			t.Errorf("String of a varchar: %q %v", v, err)
+		}
+		if v, err := r.AppendString([]byte("memo: "), notes); err != nil || string(v) != "memo: Some längere memo" {
+			t.Errorf("AppendString: %q %v", v, err)
+		}
+		if v, err := r.Bool(active); err != nil || !v {
+			t.Errorf("Bool: %v %v", v, err)
+		}
+		if v, err := r.Time(bday); err != nil || !v.Equal(birthday) {
+			t.Errorf("Time: %v %v", v, err)
+		}
+		if null, err := r.IsNull(updated); err != nil || !null {
+			t.Errorf("IsNull: %v %v", null, err)
+		}
+		if _, err := r.Bool(name); err == nil {
+			t.Errorf("Expected an error for a type mismatch")
+		}
+		if _, err := r.Int64(FieldHandle{}); err == nil {
+			t.Errorf("Expected an error for the zero handle")
+		}
+
+		buf := make([]byte, 0, 64)
+		allocs := testing.AllocsPerRun(100, func() {
+			r.Int64(id)
+			r.Float64(amount)
+			r.Bool(active)
+			r.Time(bday)
+			r.IsNull(updated)
+			buf, _ = r.AppendString(buf[:0], name)
+		})
+		if allocs != 0 {
+			t.Errorf("Expected no allocations, got %v", allocs)
+		}
+	}, ParseTrimRight)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tbl.RecordAt(0, func(r *Record) {
+		if v, err := r.Field("name"); err != nil || v != "Müller" {
+			t.Errorf("Expected case insensitive field names, got %v %v", v, err)
+		}
+	}, ParseTrimRight)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCorruptFieldHandles(t *testing.T) {
+	path := createTestTable(t)
+	tbl := openTestTableReadWrite(t, path)
+	_, err := tbl.Append(map[string]interface{}{"ID": 1, "AMOUNT": 1.5, "BIRTHDAY": time.Date(1980, 5, 17, 0, 0, 0, 0, time.Local)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	amountField, _ := tbl.FieldByName("AMOUNT")
+	bdayField, _ := tbl.FieldByName("BIRTHDAY")
+	offset := tbl.recordOffset(0)
+	tbl.Close()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(b[offset+int64(amountField.Displacement):], "     12a.5")
+	copy(b[offset+int64(bdayField.Displacement):], "1980O517")
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tbl, err = Open(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+	amount, _ := tbl.FieldHandle("AMOUNT")
+	bday, _ := tbl.FieldHandle("BIRTHDAY")
+	for _, options := range []ParseOption{ParseDefault, ParseExactDecimals} {
+		err = tbl.RecordAt(0, func(r *Record) {
+			if _, err := r.Float64(amount); err == nil {
+				t.Errorf("Expected an error for a corrupt number")
+			}
+			if _, err := r.Time(bday); err == nil {
+				t.Errorf("Expected an error for a corrupt date")
+			}
+			if _, err := r.Field("AMOUNT"); err == nil {
+				t.Errorf("Expected an error for a corrupt number")
+			}
+			if _, err := r.Field("BIRTHDAY"); err == nil {
+				t.Errorf("Expected an error for a corrupt date")
+			}
+		}, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestStringReusesPooledBuffer(t *testing.T) {
+	path := createTestTable(t)
+	tbl := openTestTableReadWrite(t, path)
+	defer tbl.Close()
+	long := strings.Repeat("x", 300)
+	if _, err := tbl.Append(map[string]interface{}{"ID": 1, "NOTES": long}); err != nil {
+		t.Fatal(err)
+	}
+	notes, _ := tbl.FieldHandle("NOTES")
+	err := tbl.RecordAt(0, func(r *Record) {
+		for i := 0; i < 3; i++ {
+			if v, err := r.String(notes); err != nil || v != long {
+				t.Fatalf("String: %q %v", v, err)
+			}
+		}
+	}, ParseTrimRight)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
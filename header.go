@@ -1,6 +1,10 @@
 package dbf
 
-import "time"
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
 
 // Type specifies the table type
 type Type byte
@@ -12,6 +16,8 @@ const (
 	TypeFoxBase Type = 0x02
 	// TypeFoxBasePlusDBaseIII ...
 	TypeFoxBasePlusDBaseIII Type = 0x03
+	// TypeDBase7 ...
+	TypeDBase7 Type = 0x04
 	// TypeVisualFoxPro ...
 	TypeVisualFoxPro Type = 0x30
 	// TypeVisualFoxProAutoInc ...
@@ -26,6 +32,8 @@ const (
 	TypeFoxBasePlusDBaseIIIMemo Type = 0x83
 	// TypeDBaseIVMemo ...
 	TypeDBaseIVMemo Type = 0x8B
+	// TypeDBase7Memo ...
+	TypeDBase7Memo Type = 0x8C
 	// TypeDBaseIVTableMemo ...
 	TypeDBaseIVTableMemo Type = 0xCB
 	// TypeFoxPro2Memo ...
@@ -34,20 +42,93 @@ const (
 	TypeFoxBase2 Type = 0xFB
 )
 
-// Flag defines flags
+// isVisualFoxPro reports whether the table uses the Visual FoxPro layout
+// (field flags, `_NullFlags` and the DBC backlink)
+func (t Type) isVisualFoxPro() bool {
+	return t == TypeVisualFoxPro || t == TypeVisualFoxProAutoInc || t == TypeVisualFoxProVar
+}
+
+// hasMemo reports whether the table has a memo file.
+// Only Visual FoxPro announces it in the header flags, other dialects use the table type.
+func (h *Header) hasMemo() bool {
+	if h.Type.isVisualFoxPro() {
+		return (h.Flags & FlagMemo) != 0
+	}
+	switch h.Type {
+	case TypeFoxPro2Memo, TypeFoxBasePlusDBaseIIIMemo, TypeDBaseIVMemo, TypeDBaseIVTableMemo, TypeDBase7Memo:
+		return true
+	}
+	return false
+}
+
+// memoFormat returns the format of the memo file used by the table type
+func (t Type) memoFormat() memoFormat {
+	switch t {
+	case TypeFoxBasePlusDBaseIIIMemo:
+		return memoDBT3
+	case TypeDBaseIVMemo, TypeDBaseIVTableMemo, TypeDBase7Memo:
+		return memoDBT4
+	}
+	return memoFPT
+}
+
+// isDBase7 reports whether the table uses the dBase 7 layout
+// (68 byte header, 48 byte field descriptors and field properties)
+func (t Type) isDBase7() bool {
+	return t == TypeDBase7 || t == TypeDBase7Memo
+}
+
+// isFoxPro reports whether the table type is written by FoxPro or Visual FoxPro
+func (t Type) isFoxPro() bool {
+	switch t {
+	case TypeFoxBasePlusDBaseIII, TypeFoxPro2Memo, TypeFoxBase2:
+		return true
+	}
+	return t.isVisualFoxPro()
+}
+
+// isDBase reports whether the table type is written by dBase IV or later
+func (t Type) isDBase() bool {
+	switch t {
+	case TypeFoxBasePlusDBaseIII, TypeDBaseIVTable, TypeDBaseIVSystem, TypeDBaseIVMemo, TypeDBaseIVTableMemo:
+		return true
+	}
+	return t.isDBase7()
+}
+
+// Flag defines flags.
+// Their meaning depends on the dialect, use the methods of Header to interpret them.
 type Flag byte
 
 const (
 	// FlagNone no flags specified
 	FlagNone Flag = 0x00
-	// FlagCDX File has a supporting structural index
+	// FlagCDX File has a supporting structural index (FoxPro)
 	FlagCDX Flag = 0x01
-	// FlagMemo File has a supporting Memo file
+	// FlagMDX File has a supporting production index (dBase IV and dBase 7)
+	FlagMDX Flag = 0x01
+	// FlagMemo File has a supporting Memo file (Visual FoxPro)
 	FlagMemo Flag = 0x02
-	// FlagDBC File is part of a DBC
+	// FlagDBC File is a DBC (Visual FoxPro)
 	FlagDBC Flag = 0x04
 )
 
+// HasCDX reports whether a FoxPro table has a structural index (.CDX).
+// FoxPro 2 and dBase IV tables without memo share the type 0x03, so both HasCDX and HasMDX may be true.
+func (h Header) HasCDX() bool {
+	return h.Type.isFoxPro() && (h.Flags&FlagCDX) != 0
+}
+
+// HasMDX reports whether a dBase IV or dBase 7 table has a production index (.MDX)
+func (h Header) HasMDX() bool {
+	return h.Type.isDBase() && (h.Flags&FlagMDX) != 0
+}
+
+// IsDBC reports whether the table is a Visual FoxPro database container
+func (h Header) IsDBC() bool {
+	return h.Type.isVisualFoxPro() && (h.Flags&FlagDBC) != 0
+}
+
 // Header defines the DBF header
 type Header struct {
 	Type         Type
@@ -62,7 +143,46 @@ type Header struct {
 	CodePage     byte
 }
 
-// LastModified returns the last modification date
+// firstYear returns the year before which no table of the type can have been written.
+// dBase III and FoxBase tables accept the 1970s, which reset clocks produce.
+func (t Type) firstYear() int {
+	switch {
+	case t.isDBase7():
+		return 1997
+	case t.isVisualFoxPro():
+		return 1995
+	case t == TypeFoxPro2Memo:
+		return 1991
+	case t == TypeDBaseIVTable, t == TypeDBaseIVSystem, t == TypeDBaseIVMemo, t == TypeDBaseIVTableMemo:
+		return 1988
+	}
+	return 1970
+}
+
+// LastModified returns the last modification date.
+// The year is stored as years since 1900, some writers store it modulo 100 instead,
+// so years that would predate the table type are read as 2000 and later.
+// E.g. 75 is 1975 for dBase III but 2075 for Visual FoxPro, 20 is 2020 for both.
 func (h Header) LastModified() time.Time {
-	return time.Date(int(h.ModYear), time.Month(h.ModMonth), int(h.ModDay), 0, 0, 0, 0, time.Local)
+	year := 1900 + int(h.ModYear)
+	if year < h.Type.firstYear() {
+		year += 100
+	}
+	return time.Date(year, time.Month(h.ModMonth), int(h.ModDay), 0, 0, 0, 0, time.Local)
+}
+
+// setLastModified stores the date part of t as the last modification date
+func (h *Header) setLastModified(t time.Time) {
+	h.ModYear = byte(t.Year() - 1900)
+	h.ModMonth = byte(t.Month())
+	h.ModDay = byte(t.Day())
+}
+
+// writeHeader writes the 32 byte header, including the two trailing reserved bytes
+func writeHeader(w io.Writer, h Header) error {
+	if err := binary.Write(w, binary.LittleEndian, &h); err != nil {
+		return err
+	}
+	_, err := w.Write([]byte{0x00, 0x00})
+	return err
 }
@@ -0,0 +1,229 @@
+package dbf
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/encoding"
+)
+
+const (
+	httpBlockSize   = 32 * 1024
+	httpCacheBlocks = 64
+)
+
+// ErrRangeNotSupported is returned when a server does not answer range requests with partial content
+var ErrRangeNotSupported = errors.New("Server does not support range requests")
+
+// HTTPReaderAt reads a remote file with HTTP range requests.
+// Blocks are fetched on demand and the most recently used blocks are cached.
+// It is safe for concurrent use.
+type HTTPReaderAt struct {
+	client *http.Client
+	url    string
+	size   int64
+
+	mu     sync.Mutex
+	blocks map[int64]*list.Element
+	lru    *list.List
+}
+
+type httpBlock struct {
+	index int64
+	data  []byte
+}
+
+// NewHTTPReaderAt prepares reading `url`. A nil client uses http.DefaultClient.
+// The first block is fetched to determine the size of the file.
+func NewHTTPReaderAt(client *http.Client, url string) (*HTTPReaderAt, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	r := &HTTPReaderAt{client: client, url: url, blocks: make(map[int64]*list.Element), lru: list.New()}
+	data, size, err := r.fetch(0)
+	if err != nil {
+		return nil, err
+	}
+	r.size = size
+	r.store(0, data)
+	return r, nil
+}
+
+// Size returns the size of the remote file
+func (r *HTTPReaderAt) Size() int64 {
+	return r.size
+}
+
+// ReadAt reads len(b) bytes at `offset`
+func (r *HTTPReaderAt) ReadAt(b []byte, offset int64) (int, error) {
+	if offset < 0 {
+		return 0, fmt.Errorf("Invalid offset %d", offset)
+	}
+	n := 0
+	for n < len(b) {
+		pos := offset + int64(n)
+		if pos >= r.size {
+			return n, io.EOF
+		}
+		index := pos / httpBlockSize
+		data, err := r.block(index)
+		if err != nil {
+			return n, err
+		}
+		start := int(pos - index*httpBlockSize)
+		if start >= len(data) {
+			return n, io.ErrUnexpectedEOF
+		}
+		n += copy(b[n:], data[start:])
+	}
+	return n, nil
+}
+
+func (r *HTTPReaderAt) block(index int64) ([]byte, error) {
+	r.mu.Lock()
+	if e, ok := r.blocks[index]; ok {
+		r.lru.MoveToFront(e)
+		r.mu.Unlock()
+		return e.Value.(*httpBlock).data, nil
+	}
+	r.mu.Unlock()
+
+	data, _, err := r.fetch(index)
+	if err != nil {
+		return nil, err
+	}
+	r.store(index, data)
+	return data, nil
+}
+
+func (r *HTTPReaderAt) store(index int64, data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.blocks[index]; ok {
+		return
+	}
+	r.blocks[index] = r.lru.PushFront(&httpBlock{index: index, data: data})
+	for r.lru.Len() > httpCacheBlocks {
+		oldest := r.lru.Back()
+		r.lru.Remove(oldest)
+		delete(r.blocks, oldest.Value.(*httpBlock).index)
+	}
+}
+
+// fetch downloads a block and returns it together with the total size of the file
+func (r *HTTPReaderAt) fetch(index int64) ([]byte, int64, error) {
+	req, err := http.NewRequest(http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	start := index * httpBlockSize
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, start+httpBlockSize-1))
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusNotFound:
+		return nil, 0, &fs.PathError{Op: "open", Path: r.url, Err: fs.ErrNotExist}
+	case http.StatusRequestedRangeNotSatisfiable:
+		// Empty files can not satisfy any range
+		if start == 0 {
+			return nil, 0, nil
+		}
+		return nil, 0, io.EOF
+	case http.StatusOK:
+		return nil, 0, fmt.Errorf("%w: %s", ErrRangeNotSupported, r.url)
+	default:
+		return nil, 0, fmt.Errorf("Could not read %s: %s", r.url, resp.Status)
+	}
+
+	// Content-Range: bytes 0-32767/123456
+	contentRange := resp.Header.Get("Content-Range")
+	i := strings.LastIndexByte(contentRange, '/')
+	if i < 0 {
+		return nil, 0, fmt.Errorf("Invalid Content-Range %q", contentRange)
+	}
+	size, err := strconv.ParseInt(contentRange[i+1:], 10, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Invalid Content-Range %q. %w", contentRange, err)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, httpBlockSize))
+	if err != nil {
+		return nil, 0, fmt.Errorf("Could not read %s. %w", r.url, err)
+	}
+	return data, size, nil
+}
+
+// httpStorage opens files with HTTP range requests
+type httpStorage struct {
+	client *http.Client
+}
+
+func (s httpStorage) open(name string) (file, error) {
+	r, err := NewHTTPReaderAt(s.client, name)
+	if err != nil {
+		return nil, err
+	}
+	return newReaderFile(name, r, r.Size(), nil), nil
+}
+
+func (s httpStorage) companion(name, ext string) (string, bool) {
+	u, err := url.Parse(name)
+	if err != nil {
+		return name, false
+	}
+	base := strings.TrimSuffix(u.Path, path.Ext(u.Path))
+	var candidates []string
+	for _, e := range []string{strings.ToUpper(ext), strings.ToLower(ext)} {
+		c := *u
+		c.Path = base + e
+		c.RawPath = ""
+		candidates = append(candidates, c.String())
+	}
+	for _, c := range candidates {
+		resp, err := s.client.Head(c)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			return c, true
+		}
+	}
+	return candidates[0], false
+}
+
+func (s httpStorage) resolve(name, rel string) string {
+	u, err := url.Parse(name)
+	if err != nil {
+		return rel
+	}
+	// Backlinks are stored with Windows path separators
+	r, err := u.Parse(strings.ReplaceAll(rel, `\`, "/"))
+	if err != nil {
+		return rel
+	}
+	return r.String()
+}
+
+// OpenURL opens a table from a server that supports HTTP range requests.
+// Only the blocks that are accessed are downloaded, the memo file, indexes and the DBC are looked up next to the table.
+// A nil client uses http.DefaultClient, a nil decoder detects the encoding from the header's code page mark.
+func OpenURL(url string, client *http.Client, decoder *encoding.Decoder) (*Dbf, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return openWith(httpStorage{client: client}, url, decoder, nil)
+}
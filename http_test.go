@@ -0,0 +1,109 @@
+package dbf
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestOpenURL(t *testing.T) {
+	var requests int32
+	files := http.FileServer(http.Dir("test"))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		files.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	tbl, err := OpenURL(srv.URL+"/contacts.dbf", srv.Client(), charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+	if err := tbl.ReadDBC(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tbl.SetOrder("BY_NAME"); err != nil {
+		t.Fatal(err)
+	}
+	recno, found, err := tbl.Seek("FULLER")
+	if err != nil || !found || recno != 2 {
+		t.Fatalf("Expected to find FULLER at 2, got %d %v %v", recno, found, err)
+	}
+
+	disk, err := Open("test/contacts.dbf", charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer disk.Close()
+	disk.ReadDBC()
+	expected, _ := recordMap(t, disk, recno)
+
+	// The record is read from the cached blocks
+	before := atomic.LoadInt32(&requests)
+	if m, _ := recordMap(t, tbl, recno); !reflect.DeepEqual(m, expected) {
+		t.Errorf("Expected %v, got %v", expected, m)
+	}
+	if n := atomic.LoadInt32(&requests) - before; n != 0 {
+		t.Errorf("Expected no additional requests, got %d", n)
+	}
+}
+
+func TestRangeNotSupported(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("no ranges"))
+	}))
+	defer srv.Close()
+
+	if _, err := OpenURL(srv.URL+"/contacts.dbf", srv.Client(), nil); !errors.Is(err, ErrRangeNotSupported) {
+		t.Errorf("Expected ErrRangeNotSupported, got %v", err)
+	}
+}
+
+func TestHTTPReaderAtBlocks(t *testing.T) {
+	data := make([]byte, httpBlockSize*(httpCacheBlocks+2)+100)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "data.bin", time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+
+	r, err := NewHTTPReaderAt(srv.Client(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Size() != int64(len(data)) {
+		t.Fatalf("Expected size %d, got %d", len(data), r.Size())
+	}
+	// Read across block borders, up to the end
+	for _, offset := range []int64{httpBlockSize - 10, 0, int64(len(data)) - 50} {
+		b := make([]byte, 60)
+		n, err := r.ReadAt(b, offset)
+		expected := data[offset:]
+		if len(expected) > len(b) {
+			expected = expected[:len(b)]
+		}
+		if n != len(expected) || !bytes.Equal(b[:n], expected) {
+			t.Errorf("Offset %d: unexpected data (%d bytes, %v)", offset, n, err)
+		}
+		if n < len(b) && err != io.EOF {
+			t.Errorf("Offset %d: expected io.EOF, got %v", offset, err)
+		}
+	}
+	if _, err := r.ReadAt(make([]byte, len(data)), 0); err != nil {
+		t.Fatal(err)
+	}
+	if r.lru.Len() != httpCacheBlocks {
+		t.Errorf("Expected %d cached blocks, got %d", httpCacheBlocks, r.lru.Len())
+	}
+}
@@ -0,0 +1,299 @@
+package dbf
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Kirides/go-dbf/cdx"
+	"github.com/Kirides/go-dbf/mdx"
+)
+
+// ErrNoIndex is returned when a table has no structural index
+var ErrNoIndex = errors.New("Table has no structural index")
+
+// indexTag is an order of a structural (.CDX) or production (.MDX) index
+type indexTag interface {
+	EncodeKey(v interface{}) ([]byte, error)
+	Range(from, to []byte, walk func(key []byte, recno uint32) error) error
+	Seek(key []byte) (uint32, bool, error)
+}
+
+// ErrNoOrder is returned by Seek when no order is set
+var ErrNoOrder = errors.New("No order set")
+
+// ErrIndexOutdated is returned for indexes of a table that was packed or zapped, their record numbers are no longer valid
+var ErrIndexOutdated = errors.New("Index is outdated, the table was packed")
+
+// closeIndexes closes the open indexes and resets the order
+func (dbf *Dbf) closeIndexes() {
+	if dbf.cdx != nil {
+		dbf.cdxFile.Close()
+		dbf.cdx = nil
+	}
+	if dbf.mdx != nil {
+		dbf.mdxFile.Close()
+		dbf.mdx = nil
+	}
+	dbf.order = nil
+	dbf.orderName = ""
+}
+
+// Indexes returns the structural index (.CDX) of the table.
+// The index is opened on first use and closed together with the table.
+func (dbf *Dbf) Indexes() (*cdx.Index, error) {
+	dbf.indexMu.Lock()
+	defer dbf.indexMu.Unlock()
+	if dbf.indexOutdated {
+		return nil, ErrIndexOutdated
+	}
+	if dbf.cdx != nil {
+		return dbf.cdx, nil
+	}
+	if !dbf.header.HasCDX() || dbf.usesMDX() {
+		return nil, ErrNoIndex
+	}
+
+	path := dbf.dbfFile.Name()
+	ext := ".CDX"
+	if strings.EqualFold(filepath.Ext(path), ".DBC") {
+		ext = ".DCX"
+	}
+	f, err := dbf.openIndexFile(ext)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := cdx.NewIndex(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("Could not read index %q. %w", f.Name(), err)
+	}
+	for _, t := range idx.Tags() {
+		typ, err := dbf.indexKeyType(t.KeyExpr)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("Could not read index %q, tag %q. %w", f.Name(), t.Name, err)
+		}
+		t.SetKeyType(typ)
+	}
+	dbf.cdx, dbf.cdxFile = idx, f
+	return idx, nil
+}
+
+// ProductionIndex returns the production index (.MDX) of a dBase IV or dBase 7 table.
+// The index is opened on first use and closed together with the table.
+func (dbf *Dbf) ProductionIndex() (*mdx.Index, error) {
+	dbf.indexMu.Lock()
+	defer dbf.indexMu.Unlock()
+	if dbf.indexOutdated {
+		return nil, ErrIndexOutdated
+	}
+	if dbf.mdx != nil {
+		return dbf.mdx, nil
+	}
+	if !dbf.usesMDX() {
+		return nil, ErrNoIndex
+	}
+	f, err := dbf.openIndexFile(".MDX")
+	if err != nil {
+		return nil, err
+	}
+	idx, err := mdx.NewIndex(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("Could not read index %q. %w", f.Name(), err)
+	}
+	dbf.mdx, dbf.mdxFile = idx, f
+	return idx, nil
+}
+
+// openIndexFile opens the index next to the table with the extension `ext`
+func (dbf *Dbf) openIndexFile(ext string) (file, error) {
+	s := readOnly(dbf.storage)
+	name, _ := s.companion(dbf.dbfFile.Name(), ext)
+	return s.open(name)
+}
+
+// usesMDX reports whether the table's index flag refers to a production index.
+// The companion lookup is done once, as it may stat the directory or send a request.
+func (dbf *Dbf) usesMDX() bool {
+	dbf.indexKindOnce.Do(func() {
+		if !dbf.header.HasMDX() {
+			return
+		}
+		if !dbf.header.HasCDX() {
+			dbf.mdxIndex = true
+			return
+		}
+		// FoxPro 2 and dBase IV share the table type, the existing index file decides
+		_, ok := dbf.storage.companion(dbf.dbfFile.Name(), ".CDX")
+		dbf.mdxIndex = !ok
+	})
+	return dbf.mdxIndex
+}
+
+// indexKeyType returns the result type of an index key expression.
+// Only expressions that consist of a single field use its type, everything else is considered to be character data.
+// Varchar and memo fields are character data, currency keys are not supported.
+func (dbf *Dbf) indexKeyType(expr string) (rune, error) {
+	expr = strings.TrimSpace(expr)
+	f, err := dbf.FieldByName(expr)
+	if err != nil && len(expr) > 10 {
+		// Field names of free tables are truncated to 10 characters
+		f, err = dbf.FieldByName(expr[:10])
+	}
+	if err != nil {
+		return 'C', nil
+	}
+	switch f.Type {
+	case 'V', 'M':
+		return 'C', nil
+	case 'Y':
+		return 0, fmt.Errorf("Index keys of currency field %q are not supported", f.Name)
+	}
+	return f.Type, nil
+}
+
+// SetOrder sets the index tag that is used by Seek and ScanOrdered.
+// An empty tag name resets the order. SetOrder must not be called while other goroutines use the table.
+func (dbf *Dbf) SetOrder(tag string) error {
+	if tag == "" {
+		dbf.order = nil
+		dbf.orderName = ""
+		return nil
+	}
+	t, name, err := dbf.tag(tag)
+	if err != nil {
+		return err
+	}
+	dbf.order = t
+	dbf.orderName = name
+	return nil
+}
+
+// Order returns the name of the current index tag or an empty string
+func (dbf *Dbf) Order() string {
+	if dbf.order == nil {
+		return ""
+	}
+	return dbf.orderName
+}
+
+// tag looks up a tag of the structural or production index and returns it with its stored name
+func (dbf *Dbf) tag(name string) (indexTag, string, error) {
+	if dbf.indexOutdated {
+		return nil, "", ErrIndexOutdated
+	}
+	if name == "" {
+		if dbf.order == nil {
+			return nil, "", ErrNoOrder
+		}
+		return dbf.order, dbf.orderName, nil
+	}
+	if dbf.usesMDX() {
+		idx, err := dbf.ProductionIndex()
+		if err != nil {
+			return nil, "", err
+		}
+		t, err := idx.Tag(name)
+		if err != nil {
+			return nil, "", err
+		}
+		return t, t.Name, nil
+	}
+	idx, err := dbf.Indexes()
+	if err != nil {
+		return nil, "", err
+	}
+	t, err := idx.Tag(name)
+	if err != nil {
+		return nil, "", err
+	}
+	return t, t.Name, nil
+}
+
+// Seek looks up `key` in the current order and returns the record number of the first matching record.
+// Character keys match by prefix (SET EXACT OFF), see cdx.Tag.EncodeKey and mdx.Tag.EncodeKey for the supported key values.
+func (dbf *Dbf) Seek(key interface{}) (uint32, bool, error) {
+	if dbf.indexOutdated {
+		return 0, false, ErrIndexOutdated
+	}
+	if dbf.order == nil {
+		return 0, false, ErrNoOrder
+	}
+	k, err := dbf.indexKey(dbf.order, key)
+	if err != nil {
+		return 0, false, err
+	}
+	return dbf.order.Seek(k)
+}
+
+// ScanOrdered walks the records in the order of `tag` from the key `from` up to and including the key `to`,
+// until the end or walk returns a non nil error.
+// A nil key is unbounded, an empty tag name uses the current order.
+func (dbf *Dbf) ScanOrdered(tag string, from, to interface{}, walk func(*Record) error, options ParseOption) error {
+	t, _, err := dbf.tag(tag)
+	if err != nil {
+		return err
+	}
+	var fromKey, toKey []byte
+	if from != nil {
+		if fromKey, err = dbf.indexKey(t, from); err != nil {
+			return err
+		}
+	}
+	if to != nil {
+		if toKey, err = dbf.indexKey(t, to); err != nil {
+			return err
+		}
+	}
+
+	r := newRecord(dbf, 0, options)
+	err = t.Range(fromKey, toKey, func(_ []byte, recno uint32) error {
+		if recno >= dbf.header.RecordCount {
+			return ErrInvalidRecordNumber
+		}
+		r.recno = recno
+		r.read = false
+		r.err = nil
+		if err := walk(r); err != nil {
+			return err
+		}
+		return r.err
+	})
+	putBuffer(r.buffer)
+	return err
+}
+
+// ErrNoEncoder is returned for keys with non-ASCII characters, if the table's decoder matches no known encoding
+var ErrNoEncoder = errors.New("No encoder for the table's code page")
+
+// indexKey converts a key value into the binary key of the tag.
+// Strings are encoded into the table's code page.
+func (dbf *Dbf) indexKey(t indexTag, key interface{}) ([]byte, error) {
+	if s, ok := key.(string); ok {
+		if dbf.encoder == nil {
+			if !isASCII(s) {
+				return nil, fmt.Errorf("Could not encode key %q. %w", s, ErrNoEncoder)
+			}
+			return t.EncodeKey([]byte(s))
+		}
+		b, err := dbf.encoder.Bytes([]byte(s))
+		if err != nil {
+			return nil, fmt.Errorf("Could not encode key %q. %w", s, err)
+		}
+		key = b
+	}
+	return t.EncodeKey(key)
+}
+
+// isASCII reports whether `s` is encoded the same in all supported code pages
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,181 @@
+package dbf
+
+import (
+	"fmt"
+	"time"
+)
+
+var typeDescriptions = map[Type]string{
+	TypeFoxBase:                 "FoxBase",
+	TypeFoxBasePlusDBaseIII:     "FoxBase+/dBase III, FoxPro 2 or dBase IV without memo",
+	TypeDBase7:                  "dBase 7 without memo",
+	TypeVisualFoxPro:            "Visual FoxPro",
+	TypeVisualFoxProAutoInc:     "Visual FoxPro with autoincrement",
+	TypeVisualFoxProVar:         "Visual FoxPro with varchar or varbinary",
+	TypeDBaseIVTable:            "dBase IV SQL table without memo",
+	TypeDBaseIVSystem:           "dBase IV SQL system file without memo",
+	TypeFoxBasePlusDBaseIIIMemo: "FoxBase+/dBase III with memo",
+	TypeDBaseIVMemo:             "dBase IV with memo",
+	TypeDBase7Memo:              "dBase 7 with memo",
+	TypeDBaseIVTableMemo:        "dBase IV SQL table with memo",
+	TypeFoxPro2Memo:             "FoxPro 2 with memo",
+	TypeFoxBase2:                "FoxBase",
+}
+
+// String returns a description of the table type
+func (t Type) String() string {
+	if s, ok := typeDescriptions[t]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown (0x%02X)", byte(t))
+}
+
+var fieldTypeNames = map[rune]string{
+	'C': "Character",
+	'V': "Varchar",
+	'Q': "Varbinary",
+	'M': "Memo",
+	'W': "Blob",
+	'G': "General",
+	'P': "Picture",
+	'D': "Date",
+	'T': "DateTime",
+	'I': "Integer",
+	'+': "Autoincrement",
+	'L': "Logical",
+	'N': "Numeric",
+	'F': "Float",
+	'Y': "Currency",
+	'B': "Double",
+	'O': "Double",
+	'@': "Timestamp",
+	'0': "Null flags",
+}
+
+// TableInfo describes the structure and metadata of a table
+type TableInfo struct {
+	// Dialect is the product that wrote the table, e.g. "Visual FoxPro" or "dBase IV"
+	Dialect string
+	Type    Type
+	// TypeDescription is the meaning of Type
+	TypeDescription string
+	LastModified    time.Time
+	HasMemo         bool
+	// Memo is the extension of the memo file (".FPT", ".DBT" or ".DCT"), empty without memo
+	Memo string
+	// Index is the extension of the structural or production index (".CDX", ".DCX" or ".MDX"), empty without index
+	Index string
+	// CodePageMark is the code page byte of the header and CodePage the Windows/DOS code page used to decode text, 0 if unknown
+	CodePageMark byte
+	CodePage     int
+	// DBC is the backlink to the database container
+	DBC string
+	// RecordCount is the number of records declared in the header,
+	// CalculatedRecordCount the number of records that fit into the file or -1
+	RecordCount           uint32
+	CalculatedRecordCount int
+	HeaderSize            uint16
+	RecordLength          uint16
+	Fields                []FieldInfo
+}
+
+// FieldInfo describes a field
+type FieldInfo struct {
+	Name string
+	Type rune
+	// TypeName is the human-readable name of Type, e.g. "Character"
+	TypeName      string
+	Length        int
+	DecimalCount  int
+	Nullable      bool
+	Binary        bool
+	AutoIncrement bool
+	// System fields like `_NullFlags` are hidden from records
+	System bool
+}
+
+// Info returns the structure and metadata of the table
+func (dbf *Dbf) Info() TableInfo {
+	h := dbf.header
+	info := TableInfo{
+		Dialect:               dbf.dialect(),
+		Type:                  h.Type,
+		TypeDescription:       h.Type.String(),
+		LastModified:          h.LastModified(),
+		HasMemo:               dbf.memoFile != nil,
+		CodePageMark:          h.CodePage,
+		CodePage:              dbf.CodePage(),
+		DBC:                   dbf.DBC(),
+		RecordCount:           h.RecordCount,
+		CalculatedRecordCount: dbf.CalculatedRecordCount(),
+		HeaderSize:            h.HeaderSize,
+		RecordLength:          h.RecordLength,
+	}
+	if info.HasMemo {
+		// the name of the memo file depends on the source, e.g. a URL or OpenReaderAt
+		info.Memo = memoExtension(h, dbf.dbfFile.Name())
+	}
+	switch {
+	case dbf.usesMDX():
+		info.Index = ".MDX"
+	case h.HasCDX():
+		info.Index = ".CDX"
+		if h.IsDBC() {
+			info.Index = ".DCX"
+		}
+	}
+	for _, f := range dbf.fields {
+		info.Fields = append(info.Fields, FieldInfo{
+			Name:          f.Name,
+			Type:          f.Type,
+			TypeName:      fieldTypeName(&f),
+			Length:        int(f.Length),
+			DecimalCount:  int(f.DecimalCount),
+			Nullable:      (f.Flags & FieldFlagNull) != 0,
+			Binary:        (f.Flags&FieldFlagBinary) != 0 || f.Type == 'Q',
+			AutoIncrement: (f.Flags&FieldFlagAutoInc) == FieldFlagAutoInc || f.Type == '+',
+			System:        (f.Flags & FieldFlagSystem) != 0,
+		})
+	}
+	return info
+}
+
+// dialect returns the name of the product that wrote the table
+func (dbf *Dbf) dialect() string {
+	t := dbf.header.Type
+	switch {
+	case t.isVisualFoxPro():
+		return "Visual FoxPro"
+	case t.isDBase7():
+		return "dBase 7"
+	case t == TypeFoxBase || t == TypeFoxBase2:
+		return "FoxBase"
+	case t == TypeFoxPro2Memo:
+		return "FoxPro 2"
+	case t == TypeFoxBasePlusDBaseIIIMemo:
+		return "dBase III"
+	case t == TypeFoxBasePlusDBaseIII:
+		// Type 0x03 is shared, the index flag tells them apart
+		if dbf.usesMDX() {
+			return "dBase IV"
+		}
+		if dbf.header.HasCDX() {
+			return "FoxPro 2"
+		}
+		return "dBase III"
+	case t.isDBase():
+		return "dBase IV"
+	}
+	return "Unknown"
+}
+
+func fieldTypeName(f *Field) string {
+	if f.Type == 'B' && f.Length == 10 {
+		// dBase binary memo
+		return "Binary"
+	}
+	if name, ok := fieldTypeNames[f.Type]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown (%q)", f.Type)
+}
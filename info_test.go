@@ -0,0 +1,94 @@
+package dbf
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestLastModified(t *testing.T) {
+	cases := []struct {
+		typ      Type
+		year     byte
+		expected int
+	}{
+		{TypeVisualFoxPro, 126, 2026}, {TypeVisualFoxPro, 20, 2020}, {TypeVisualFoxPro, 97, 1997},
+		{TypeVisualFoxPro, 94, 2094}, {TypeVisualFoxPro, 95, 1995},
+		{TypeFoxBasePlusDBaseIII, 80, 1980}, {TypeFoxBasePlusDBaseIII, 75, 1975},
+		{TypeFoxBasePlusDBaseIII, 70, 1970}, {TypeFoxBasePlusDBaseIII, 69, 2069}, {TypeFoxBasePlusDBaseIIIMemo, 5, 2005},
+		{TypeDBaseIVTable, 87, 2087}, {TypeDBaseIVTable, 88, 1988}, {TypeDBase7, 96, 2096}, {TypeDBase7, 123, 2023},
+	}
+	for _, c := range cases {
+		h := Header{Type: c.typ, ModYear: c.year, ModMonth: 3, ModDay: 4}
+		if y := h.LastModified().Year(); y != c.expected {
+			t.Errorf("Type 0x%02X ModYear %d: expected %d, got %d", byte(c.typ), c.year, c.expected, y)
+		}
+	}
+	var h Header
+	h.setLastModified(time.Date(2026, 10, 17, 0, 0, 0, 0, time.Local))
+	if !h.LastModified().Equal(time.Date(2026, 10, 17, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("Unexpected date %v", h.LastModified())
+	}
+}
+
+func TestInfo(t *testing.T) {
+	tbl, err := Open("test/contacts.dbf", charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+
+	info := tbl.Info()
+	if info.Dialect != "Visual FoxPro" || info.Type != TypeVisualFoxPro || info.TypeDescription != "Visual FoxPro" {
+		t.Errorf("Unexpected dialect %q %v", info.Dialect, info.Type)
+	}
+	if !info.LastModified.Equal(time.Date(2020, 3, 4, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("Unexpected last modification %v", info.LastModified)
+	}
+	if !info.HasMemo || info.Memo != ".FPT" || info.Index != ".CDX" || info.DBC != "contacts.dbc" {
+		t.Errorf("Unexpected companions %+v", info)
+	}
+	if info.CodePageMark != 0x03 || info.CodePage != 1252 {
+		t.Errorf("Unexpected code page 0x%02X %d", info.CodePageMark, info.CodePage)
+	}
+	if int(info.RecordCount) != info.CalculatedRecordCount {
+		t.Errorf("Expected %d records, calculated %d", info.RecordCount, info.CalculatedRecordCount)
+	}
+	if len(info.Fields) == 0 || info.Fields[0].TypeName != "Integer" {
+		t.Errorf("Unexpected fields %+v", info.Fields)
+	}
+	last := info.Fields[len(info.Fields)-1]
+	if last.Name != "_NullFlags" || !last.System {
+		t.Errorf("Unexpected system field %+v", last)
+	}
+}
+
+func TestInfoMemoOfReaders(t *testing.T) {
+	b, err := os.ReadFile("test/contacts.dbf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	memo, err := os.ReadFile("test/contacts.FPT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbl, err := OpenBytes(b, memo, charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+	if info := tbl.Info(); !info.HasMemo || info.Memo != ".FPT" {
+		t.Errorf("Expected the .FPT memo, got %v %q", info.HasMemo, info.Memo)
+	}
+
+	tbl, err = Open("test/contacts.dbc", charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+	if info := tbl.Info(); info.Memo != ".DCT" {
+		t.Errorf("Expected the .DCT memo of the container, got %q", info.Memo)
+	}
+}
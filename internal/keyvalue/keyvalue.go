@@ -0,0 +1,47 @@
+// Package keyvalue converts Go values into the numbers and dates that index keys are built from.
+// It is shared by the cdx, mdx and ntx packages.
+package keyvalue
+
+import "time"
+
+// Float returns the numeric value of integers, floats and types with a Float64 method like dbf.Decimal
+func Float(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	case interface{ Float64() float64 }:
+		return n.Float64(), true
+	}
+	return 0, false
+}
+
+// JulianDay returns the julian day number of the date of t
+func JulianDay(t time.Time) int {
+	y, m, d := t.Date()
+	a := (14 - int(m)) / 12
+	y2 := y + 4800 - a
+	m2 := int(m) + 12*a - 3
+	return d + (153*m2+2)/5 + 365*y2 + y2/4 - y2/100 + y2/400 - 32045
+}
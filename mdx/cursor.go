@@ -0,0 +1,110 @@
+package mdx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// errStop ends a walk early
+var errStop = errors.New("stop")
+
+// compare compares the stored key `k` with `key` in stored order.
+// Character keys are compared by their prefix, numeric and date keys by their value.
+func (t *Tag) compare(k, key []byte) int {
+	var cmp int
+	switch {
+	case t.KeyType == 'N' && len(key) == numericKeySize:
+		cmp = compareFloat(decodeNumeric(k), decodeNumeric(key))
+	case t.KeyType == 'D' && len(key) == dateKeySize:
+		cmp = compareFloat(decodeDate(k), decodeDate(key))
+	default:
+		if len(k) > len(key) {
+			k = k[:len(key)]
+		}
+		cmp = bytes.Compare(k, key)
+	}
+	if t.Descending {
+		return -cmp
+	}
+	return cmp
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Walk passes every key of the tag in order. dBase numbers records from 1, walk gets them zero based for dbf.Dbf.RecordAt.
+// The key points into the page and is only valid until walk returns.
+func (t *Tag) Walk(walk func(key []byte, recno uint32) error) error {
+	return t.Range(nil, nil, walk)
+}
+
+// Range walks the keys of the tag from `from` up to and including `to`, a nil bound is open.
+// Character keys match by prefix, BCD numbers and julian dates by value, see EncodeKey.
+// Descending tags run from the larger to the smaller key, so `from` is the larger key.
+func (t *Tag) Range(from, to []byte, walk func(key []byte, recno uint32) error) error {
+	err := t.visit(t.root, from, to, walk, 0)
+	if err == errStop {
+		return nil
+	}
+	return err
+}
+
+// visit walks the subtree at `block` in order. It returns errStop after passing `to`.
+func (t *Tag) visit(block uint32, from, to []byte, walk func(key []byte, recno uint32) error, depth int) error {
+	if depth > 32 {
+		return fmt.Errorf("Tag %q: invalid index tree at page %d", t.Name, block)
+	}
+	p, err := t.readPage(block)
+	if err != nil {
+		return err
+	}
+	i := 0
+	if from != nil {
+		for i < p.count && t.compare(p.key(i), from) < 0 {
+			i++
+		}
+	}
+	if p.leaf() {
+		for ; i < p.count; i++ {
+			key := p.key(i)
+			if to != nil && t.compare(key, to) > 0 {
+				return errStop
+			}
+			if err := walk(key, p.pointer(i)-1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	// Interior keys are the last key of their child, the pointer after the last key holds the remaining keys
+	for ; i <= p.count; i++ {
+		if err := t.visit(p.pointer(i), from, to, walk, depth+1); err != nil {
+			return err
+		}
+		from = nil
+	}
+	return nil
+}
+
+// Seek returns the zero based record number of the first key of the tag that matches `key`, by prefix for character keys
+func (t *Tag) Seek(key []byte) (uint32, bool, error) {
+	var recno uint32
+	found := false
+	err := t.Range(key, key, func(_ []byte, r uint32) error {
+		recno = r
+		found = true
+		return errStop
+	})
+	if err == errStop {
+		err = nil
+	}
+	return recno, found, err
+}
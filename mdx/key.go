@@ -0,0 +1,122 @@
+package mdx
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kirides/go-dbf/internal/keyvalue"
+)
+
+const (
+	// numericKeySize is the size of a BCD encoded numeric key
+	numericKeySize = 12
+	// dateKeySize is the size of a date key, the julian day number as a little endian float64
+	dateKeySize = 8
+	// maxDigits is the number of BCD digits in a numeric key
+	maxDigits = 2 * (numericKeySize - 2)
+	// exponentBias is added to the decimal exponent of numeric keys
+	exponentBias = 0x34
+	signNegative = 0x80
+)
+
+// EncodeKey converts a value into the key format of the tag.
+// Character keys take a string or []byte that is already in the code page of the table.
+// Numeric keys take integers or floats and date keys a time.Time.
+func (t *Tag) EncodeKey(v interface{}) ([]byte, error) {
+	var key []byte
+	switch t.KeyType {
+	case 'C':
+		switch s := v.(type) {
+		case string:
+			key = []byte(s)
+		case []byte:
+			key = s
+		}
+	case 'N':
+		if f, ok := keyvalue.Float(v); ok && !math.IsNaN(f) && !math.IsInf(f, 0) {
+			key = encodeNumeric(f)
+		}
+	case 'D':
+		if d, ok := v.(time.Time); ok {
+			key = make([]byte, dateKeySize)
+			if !d.IsZero() {
+				binary.LittleEndian.PutUint64(key, math.Float64bits(float64(keyvalue.JulianDay(d))))
+			}
+		}
+	}
+	if key == nil {
+		return nil, fmt.Errorf("Tag %q: invalid key %v (%T) for key type %q", t.Name, v, v, t.KeyType)
+	}
+	if len(key) > t.KeyLength {
+		return nil, fmt.Errorf("Key exceeds the key length of %d", t.KeyLength)
+	}
+	return key, nil
+}
+
+// encodeNumeric stores `f` as a normalized BCD mantissa with a decimal exponent:
+// byte 0 holds the exponent plus 0x34, byte 1 the number of digits shifted by two and the sign,
+// the remaining bytes hold two digits each.
+func encodeNumeric(f float64) []byte {
+	key := make([]byte, numericKeySize)
+	key[0] = exponentBias
+	if f == 0 {
+		return key
+	}
+	if f < 0 {
+		key[1] = signNegative
+		f = -f
+	}
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	intPart, frac, _ := strings.Cut(s, ".")
+	intPart = strings.TrimLeft(intPart, "0")
+	exponent := len(intPart)
+	digits := intPart + frac
+	if intPart == "" {
+		trimmed := strings.TrimLeft(frac, "0")
+		exponent = len(trimmed) - len(frac)
+		digits = trimmed
+	}
+	digits = strings.TrimRight(digits, "0")
+	if len(digits) > maxDigits {
+		digits = digits[:maxDigits]
+	}
+	key[0] = byte(exponentBias + exponent)
+	key[1] |= byte(len(digits) << 2)
+	for i := 0; i < len(digits); i++ {
+		d := digits[i] - '0'
+		if i%2 == 0 {
+			key[2+i/2] = d << 4
+		} else {
+			key[2+i/2] |= d
+		}
+	}
+	return key
+}
+
+func decodeNumeric(key []byte) float64 {
+	count := int(key[1]>>2) & 0x1F
+	if count > maxDigits {
+		count = maxDigits
+	}
+	var mantissa float64
+	for i := 0; i < count; i++ {
+		d := key[2+i/2]
+		if i%2 == 0 {
+			d >>= 4
+		}
+		mantissa = mantissa*10 + float64(d&0x0F)
+	}
+	f := mantissa * math.Pow10(int(key[0])-exponentBias-count)
+	if (key[1] & signNegative) != 0 {
+		return -f
+	}
+	return f
+}
+
+func decodeDate(key []byte) float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(key))
+}
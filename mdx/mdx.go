@@ -0,0 +1,232 @@
+// Package mdx reads dBase IV and dBase 7 multiple index files (.MDX)
+package mdx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// blockSize is the unit of all page numbers in the file
+	blockSize = 512
+	// tagTableOffset is the position of the first entry of the tag table
+	tagTableOffset = 0x220
+	tagEntrySize   = 32
+	maxTags        = 47
+	exprSize       = 220
+	// pageHeader holds the number of keys and the previous page, the entries follow it
+	pageHeader = 8
+)
+
+// Tag header layout
+const (
+	tagRoot       = 0x00
+	tagKeyFormat  = 0x08
+	tagKeyType    = 0x09
+	tagKeyLength  = 0x0C
+	tagMaxKeys    = 0x0E
+	tagItemLength = 0x12
+	tagUnique     = 0x17
+	tagKeyExpr    = 0x18
+	tagHasFor     = 0xF6
+	tagForExpr    = 0x294
+)
+
+// Key format flags
+const (
+	formatDescending = 0x08
+	formatUnique     = 0x40
+)
+
+// ErrTagNotFound is returned when a tag does not exist in the index
+var ErrTagNotFound = errors.New("Tag not found")
+
+// Index is a multiple index containing up to 47 tags
+type Index struct {
+	r        io.ReaderAt
+	closer   io.Closer
+	pageSize int
+	tags     []*Tag
+}
+
+// Open opens the multiple index file at `path`
+func Open(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := NewIndex(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("Could not read index %q. %w", path, err)
+	}
+	idx.closer = f
+	return idx, nil
+}
+
+// NewIndex reads a multiple index from `r`
+func NewIndex(r io.ReaderAt) (*Index, error) {
+	buf := make([]byte, tagTableOffset+maxTags*tagEntrySize)
+	if _, err := r.ReadAt(buf, 0); err != nil {
+		return nil, fmt.Errorf("Could not read index header. %w", err)
+	}
+	if buf[0] != 0x02 {
+		return nil, fmt.Errorf("Not an MDX index (version 0x%02X)", buf[0])
+	}
+	idx := &Index{r: r, pageSize: int(binary.LittleEndian.Uint16(buf[22:]))}
+	if idx.pageSize < 2*blockSize || idx.pageSize%blockSize != 0 {
+		return nil, fmt.Errorf("Invalid page size %d", idx.pageSize)
+	}
+	entrySize := int(buf[26])
+	if entrySize == 0 {
+		entrySize = tagEntrySize
+	}
+	count := int(binary.LittleEndian.Uint16(buf[28:]))
+	if count > maxTags || entrySize < tagEntrySize {
+		return nil, fmt.Errorf("Invalid tag table (%d tags)", count)
+	}
+	if entrySize != tagEntrySize {
+		buf = make([]byte, tagTableOffset+count*entrySize)
+		if _, err := r.ReadAt(buf, 0); err != nil {
+			return nil, fmt.Errorf("Could not read tag table. %w", err)
+		}
+	}
+	for i := 0; i < count; i++ {
+		entry := buf[tagTableOffset+i*entrySize:]
+		name := string(bytes.TrimRight(cString(entry[4:15]), " "))
+		t, err := idx.readTag(binary.LittleEndian.Uint32(entry[0:]), name)
+		if err != nil {
+			return nil, err
+		}
+		idx.tags = append(idx.tags, t)
+	}
+	return idx, nil
+}
+
+func cString(b []byte) []byte {
+	if i := bytes.IndexByte(b, 0x00); i >= 0 {
+		return b[:i]
+	}
+	return b
+}
+
+// Close closes the underlying file if the index was opened with Open
+func (idx *Index) Close() error {
+	if idx.closer != nil {
+		return idx.closer.Close()
+	}
+	return nil
+}
+
+// Tags returns all tags of the index
+func (idx *Index) Tags() []*Tag {
+	return idx.tags
+}
+
+// Tag returns the tag with the specified name (Case insensitive)
+func (idx *Index) Tag(name string) (*Tag, error) {
+	for _, t := range idx.tags {
+		if strings.EqualFold(t.Name, name) {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("%w %q", ErrTagNotFound, name)
+}
+
+// Tag is a single index order inside a multiple index
+type Tag struct {
+	Name    string
+	KeyExpr string
+	ForExpr string
+	// KeyType is the type of the stored keys, 'C' (character), 'N' (numeric) or 'D' (date)
+	KeyType    rune
+	KeyLength  int
+	Descending bool
+	Unique     bool
+
+	idx      *Index
+	root     uint32
+	itemSize int
+	maxKeys  int
+}
+
+func (idx *Index) readTag(block uint32, name string) (*Tag, error) {
+	// the tag header occupies a whole page
+	buf := make([]byte, idx.pageSize)
+	if _, err := idx.r.ReadAt(buf, int64(block)*blockSize); err != nil {
+		return nil, fmt.Errorf("Could not read header of tag %q. %w", name, err)
+	}
+	format := buf[tagKeyFormat]
+	t := &Tag{
+		Name:       name,
+		KeyExpr:    string(bytes.TrimSpace(cString(buf[tagKeyExpr : tagKeyExpr+exprSize]))),
+		KeyType:    rune(buf[tagKeyType]),
+		KeyLength:  int(binary.LittleEndian.Uint16(buf[tagKeyLength:])),
+		Descending: (format & formatDescending) != 0,
+		Unique:     (format&formatUnique) != 0 || buf[tagUnique] != 0,
+		idx:        idx,
+		root:       binary.LittleEndian.Uint32(buf[tagRoot:]),
+		itemSize:   int(binary.LittleEndian.Uint16(buf[tagItemLength:])),
+		maxKeys:    int(binary.LittleEndian.Uint16(buf[tagMaxKeys:])),
+	}
+	if buf[tagHasFor] != 0 {
+		t.ForExpr = string(bytes.TrimSpace(cString(buf[tagForExpr : tagForExpr+exprSize])))
+	}
+	switch t.KeyType {
+	case 'C':
+	case 'N', 'F':
+		t.KeyType = 'N'
+		if t.KeyLength != numericKeySize {
+			return nil, fmt.Errorf("Tag %q: invalid numeric key length %d", name, t.KeyLength)
+		}
+	case 'D':
+		if t.KeyLength != dateKeySize {
+			return nil, fmt.Errorf("Tag %q: invalid date key length %d", name, t.KeyLength)
+		}
+	default:
+		return nil, fmt.Errorf("Tag %q: unsupported key type %q", name, t.KeyType)
+	}
+	if t.KeyLength <= 0 || t.itemSize < t.KeyLength+4 || pageHeader+(t.maxKeys+1)*t.itemSize > idx.pageSize {
+		return nil, fmt.Errorf("Tag %q: invalid key length %d", name, t.KeyLength)
+	}
+	return t, nil
+}
+
+type page struct {
+	tag   *Tag
+	buf   []byte
+	count int
+}
+
+func (t *Tag) readPage(block uint32) (*page, error) {
+	buf := make([]byte, t.idx.pageSize)
+	if _, err := t.idx.r.ReadAt(buf, int64(block)*blockSize); err != nil {
+		return nil, fmt.Errorf("Could not read page %d. %w", block, err)
+	}
+	p := &page{tag: t, buf: buf, count: int(binary.LittleEndian.Uint32(buf))}
+	if p.count > t.maxKeys {
+		return nil, fmt.Errorf("Corrupt page %d", block)
+	}
+	return p, nil
+}
+
+// pointer returns the record number of entry `i` in leaf pages or its child page in interior pages.
+// Interior pages have one more pointer than keys.
+func (p *page) pointer(i int) uint32 {
+	return binary.LittleEndian.Uint32(p.buf[pageHeader+i*p.tag.itemSize:])
+}
+
+func (p *page) key(i int) []byte {
+	pos := pageHeader + i*p.tag.itemSize + 4
+	return p.buf[pos : pos+p.tag.KeyLength]
+}
+
+// leaf reports whether the page holds record numbers, interior pages have a pointer after the last key
+func (p *page) leaf() bool {
+	return p.pointer(p.count) == 0
+}
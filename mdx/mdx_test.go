@@ -0,0 +1,209 @@
+package mdx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+)
+
+const testPageSize = 1024
+
+type testTag struct {
+	name       string
+	expr       string
+	forExpr    string
+	keyType    byte
+	keyLength  int
+	descending bool
+	// pages[0] is the root, interior pages reference other pages by their index
+	pages []testPage
+}
+
+type testPage struct {
+	keys     [][]byte
+	pointers []uint32
+	interior bool
+}
+
+// buildIndex lays out the file header, the tag table, one tag header page per tag
+// and the B-tree pages of each tag
+func buildIndex(t *testing.T, tags []testTag) []byte {
+	const headerPages = 2
+	blocksPerPage := uint32(testPageSize / blockSize)
+	buf := make([]byte, headerPages*testPageSize)
+	buf[0] = 0x02
+	binary.LittleEndian.PutUint16(buf[20:], uint16(blocksPerPage))
+	binary.LittleEndian.PutUint16(buf[22:], testPageSize)
+	buf[24] = 0x01
+	buf[25] = maxTags + 1
+	buf[26] = tagEntrySize
+	binary.LittleEndian.PutUint16(buf[28:], uint16(len(tags)))
+
+	next := uint32(headerPages)
+	for i, tag := range tags {
+		headerPage := next
+		firstPage := next + 1
+		next += 1 + uint32(len(tag.pages))
+
+		entry := buf[tagTableOffset+i*tagEntrySize:]
+		binary.LittleEndian.PutUint32(entry, headerPage*blocksPerPage)
+		copy(entry[4:15], tag.name)
+		entry[20] = tag.keyType
+
+		itemSize := (tag.keyLength + 4 + 3) / 4 * 4
+		header := make([]byte, testPageSize)
+		binary.LittleEndian.PutUint32(header[tagRoot:], firstPage*blocksPerPage)
+		if tag.descending {
+			header[tagKeyFormat] = formatDescending
+		}
+		header[tagKeyType] = tag.keyType
+		binary.LittleEndian.PutUint16(header[tagKeyLength:], uint16(tag.keyLength))
+		binary.LittleEndian.PutUint16(header[tagMaxKeys:], uint16((testPageSize-pageHeader)/itemSize-1))
+		binary.LittleEndian.PutUint16(header[tagItemLength:], uint16(itemSize))
+		copy(header[tagKeyExpr:], tag.expr)
+		if tag.forExpr != "" {
+			header[tagHasFor] = 1
+			copy(header[tagForExpr:], tag.forExpr)
+		}
+		buf = append(buf, header...)
+
+		for _, p := range tag.pages {
+			page := make([]byte, testPageSize)
+			binary.LittleEndian.PutUint32(page, uint32(len(p.keys)))
+			for j, ptr := range p.pointers {
+				if p.interior {
+					ptr = (firstPage + ptr) * blocksPerPage
+				}
+				binary.LittleEndian.PutUint32(page[pageHeader+j*itemSize:], ptr)
+				if j < len(p.keys) {
+					copy(page[pageHeader+4+j*itemSize:], p.keys[j])
+				}
+			}
+			buf = append(buf, page...)
+		}
+	}
+	return buf
+}
+
+func charKey(s string, length int) []byte {
+	return []byte(fmt.Sprintf("%-*s", length, s))
+}
+
+func testIndex(t *testing.T) *Index {
+	names := []string{"ADAMS", "BAKER", "CLARK", "DAVIS", "EVANS", "FOX", "GREEN"}
+	leaf := func(from, to int) testPage {
+		p := testPage{}
+		for i := from; i < to; i++ {
+			p.keys = append(p.keys, charKey(names[i], 8))
+			p.pointers = append(p.pointers, uint32(len(names)-i))
+		}
+		return p
+	}
+	nameTag := testTag{name: "NAME", expr: "UPPER(NAME)", forExpr: ".NOT.DELETED()", keyType: 'C', keyLength: 8,
+		pages: []testPage{
+			{keys: [][]byte{charKey("BAKER", 8), charKey("DAVIS", 8)}, pointers: []uint32{1, 2, 3}, interior: true},
+			leaf(0, 2), leaf(2, 4), leaf(4, 7),
+		}}
+
+	amounts := []float64{250, 12.5, 0, -3, -1000}
+	amountLeaf := testPage{}
+	for i, a := range amounts {
+		amountLeaf.keys = append(amountLeaf.keys, encodeNumeric(a))
+		amountLeaf.pointers = append(amountLeaf.pointers, uint32(i+1))
+	}
+	amountTag := testTag{name: "AMOUNT", expr: "AMOUNT", keyType: 'N', keyLength: numericKeySize, descending: true,
+		pages: []testPage{amountLeaf}}
+
+	idx, err := NewIndex(bytes.NewReader(buildIndex(t, []testTag{nameTag, amountTag})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return idx
+}
+
+func TestTags(t *testing.T) {
+	idx := testIndex(t)
+	if len(idx.Tags()) != 2 {
+		t.Fatalf("Expected 2 tags, got %d", len(idx.Tags()))
+	}
+	tag, err := idx.Tag("name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag.KeyExpr != "UPPER(NAME)" || tag.ForExpr != ".NOT.DELETED()" || tag.KeyType != 'C' || tag.KeyLength != 8 || tag.Descending {
+		t.Errorf("Unexpected tag %+v", tag)
+	}
+	if _, err := idx.Tag("missing"); err == nil {
+		t.Errorf("Expected an error for a missing tag")
+	}
+}
+
+func TestWalkAndSeek(t *testing.T) {
+	tag, _ := testIndex(t).Tag("NAME")
+	var names []string
+	var recnos []uint32
+	err := tag.Walk(func(key []byte, recno uint32) error {
+		names = append(names, string(bytes.TrimSpace(key)))
+		recnos = append(recnos, recno)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(names) != "[ADAMS BAKER CLARK DAVIS EVANS FOX GREEN]" || fmt.Sprint(recnos) != "[6 5 4 3 2 1 0]" {
+		t.Errorf("Unexpected walk %v %v", names, recnos)
+	}
+
+	names = nil
+	err = tag.Range([]byte("C"), []byte("F"), func(key []byte, _ uint32) error {
+		names = append(names, string(bytes.TrimSpace(key)))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(names) != "[CLARK DAVIS EVANS FOX]" {
+		t.Errorf("Unexpected range %v", names)
+	}
+
+	if recno, found, err := tag.Seek([]byte("EV")); err != nil || !found || recno != 2 {
+		t.Errorf("Expected to find EVANS at 2, got %d %v %v", recno, found, err)
+	}
+	if _, found, _ := tag.Seek([]byte("HILL")); found {
+		t.Errorf("Did not expect to find HILL")
+	}
+}
+
+func TestNumericKeys(t *testing.T) {
+	tag, _ := testIndex(t).Tag("AMOUNT")
+	if tag.KeyType != 'N' || !tag.Descending {
+		t.Errorf("Unexpected tag %+v", tag)
+	}
+	var values []float64
+	err := tag.Walk(func(key []byte, _ uint32) error {
+		values = append(values, decodeNumeric(key))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(values) != "[250 12.5 0 -3 -1000]" {
+		t.Errorf("Unexpected values %v", values)
+	}
+
+	from, _ := tag.EncodeKey(12.5)
+	to, _ := tag.EncodeKey(-3)
+	var recnos []uint32
+	tag.Range(from, to, func(_ []byte, recno uint32) error {
+		recnos = append(recnos, recno)
+		return nil
+	})
+	if fmt.Sprint(recnos) != "[1 2 3]" {
+		t.Errorf("Unexpected range %v", recnos)
+	}
+	key, _ := tag.EncodeKey(0.125)
+	if decodeNumeric(key) != 0.125 || key[0] != exponentBias {
+		t.Errorf("Unexpected key % X", key)
+	}
+}
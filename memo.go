@@ -0,0 +1,189 @@
+package dbf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	memoTypePicture = 0
+	memoTypeText    = 1
+
+	dbtBlockSize = 512
+)
+
+// memoFormat is the layout of a memo file
+type memoFormat byte
+
+const (
+	// memoFPT FoxPro memo, every block starts with its type and length
+	memoFPT memoFormat = iota
+	// memoDBT3 dBase III memo, 512 byte blocks terminated by 0x1A
+	memoDBT3
+	// memoDBT4 dBase IV memo, every block starts with a signature and its length
+	memoDBT4
+)
+
+// dbt4Signature starts every used block of a dBase IV memo file
+var dbt4Signature = []byte{0xFF, 0xFF, 0x08, 0x00}
+
+// ErrNoMemoFile is returned when writing memo data to a table without memo file
+var ErrNoMemoFile = errors.New("Table has no memo file")
+
+// ErrUnsupportedMemoFormat is returned when writing to a dBase memo file (.DBT)
+var ErrUnsupportedMemoFormat = errors.New("Writing dBase memo files is not supported")
+
+// memoBlock returns the memo block a memo field points to.
+// Visual FoxPro stores a 4 byte integer, older formats 10 ASCII digits.
+func memoBlock(b []byte) uint32 {
+	if len(b) == 4 {
+		return binary.LittleEndian.Uint32(b)
+	}
+	v, _ := strconv.ParseUint(string(bytes.TrimSpace(b)), 10, 32)
+	return uint32(v)
+}
+
+func putMemoBlock(b []byte, block uint32) {
+	if len(b) == 4 {
+		binary.LittleEndian.PutUint32(b, block)
+		return
+	}
+	s := ""
+	if block != 0 {
+		s = strconv.FormatUint(uint64(block), 10)
+	}
+	pad := len(b) - len(s)
+	for i := 0; i < pad; i++ {
+		b[i] = ' '
+	}
+	copy(b[pad:], s)
+}
+
+// readMemoBlockSize reads the block size from the memo file header
+func readMemoBlockSize(f file, format memoFormat) (int64, error) {
+	var buf [2]byte
+	switch format {
+	case memoDBT3:
+		return dbtBlockSize, nil
+	case memoDBT4:
+		if _, err := f.ReadAt(buf[:], 20); err != nil {
+			return 0, fmt.Errorf("Could not read memo header. %w", err)
+		}
+		if size := binary.LittleEndian.Uint16(buf[:]); size != 0 {
+			return int64(size), nil
+		}
+		return dbtBlockSize, nil
+	}
+	if _, err := f.ReadAt(buf[:], 6); err != nil {
+		return 0, fmt.Errorf("Could not read memo header. %w", err)
+	}
+	return int64(binary.BigEndian.Uint16(buf[:])), nil
+}
+
+// readDBTMemo reads a dBase memo starting at `block`.
+// dBase IV blocks carry their length, dBase III memos end at the first 0x1A.
+// buf has to be returned with putBuffer.
+func (dbf *Dbf) readDBTMemo(block uint32) (memo []byte, buf []byte, err error) {
+	offset := int64(block) * dbf.memoBlockSize
+	if dbf.memoFormat == memoDBT4 {
+		var header [8]byte
+		if _, err := dbf.memoFile.ReadAt(header[:], offset); err != nil {
+			return nil, nil, err
+		}
+		if bytes.Equal(header[:4], dbt4Signature) {
+			size := int(binary.LittleEndian.Uint32(header[4:])) - len(header)
+			if size <= 0 {
+				return nil, nil, nil
+			}
+			buf = getBuffer(size)
+			if _, err := dbf.memoFile.ReadAt(buf[:size], offset+int64(len(header))); err != nil {
+				putBuffer(buf)
+				return nil, nil, err
+			}
+			return buf[:size], buf, nil
+		}
+	}
+
+	buf = getBuffer(int(dbf.memoBlockSize))
+	n := 0
+	for {
+		if n == len(buf) {
+			grown := getBuffer(2 * len(buf))
+			copy(grown, buf[:n])
+			putBuffer(buf)
+			buf = grown
+		}
+		m, err := dbf.memoFile.ReadAt(buf[n:], offset+int64(n))
+		if i := bytes.IndexByte(buf[n:n+m], eofMarker); i >= 0 {
+			return buf[:n+i], buf, nil
+		}
+		n += m
+		if err == io.EOF {
+			return buf[:n], buf, nil
+		}
+		if err != nil {
+			putBuffer(buf)
+			return nil, nil, err
+		}
+	}
+}
+
+// writeMemo stores `data` in the memo file and points the memo field `b` to it.
+// The previous block is reused when the data fits into it.
+func (dbf *Dbf) writeMemo(b []byte, data []byte, memoType uint32) error {
+	if len(data) == 0 {
+		putMemoBlock(b, 0)
+		return nil
+	}
+	if dbf.memoFile == nil {
+		return ErrNoMemoFile
+	}
+	w, ok := dbf.memoFile.(writableFile)
+	if !dbf.writable || !ok {
+		return ErrReadOnly
+	}
+	if dbf.memoFormat != memoFPT {
+		return ErrUnsupportedMemoFormat
+	}
+
+	blockSize := dbf.memoBlockSize
+	blocks := (8 + int64(len(data)) + blockSize - 1) / blockSize
+	intBuf := make([]byte, 4)
+
+	block := uint32(0)
+	if old := memoBlock(b); old != 0 {
+		if _, err := dbf.memoFile.ReadAt(intBuf, int64(old)*blockSize+4); err != nil {
+			return fmt.Errorf("Could not read memo block %d. %w", old, err)
+		}
+		oldBlocks := (8 + int64(binary.BigEndian.Uint32(intBuf)) + blockSize - 1) / blockSize
+		if blocks <= oldBlocks {
+			block = old
+		}
+	}
+
+	buf := make([]byte, 8+len(data))
+	if block == 0 {
+		if _, err := dbf.memoFile.ReadAt(intBuf, 0); err != nil {
+			return fmt.Errorf("Could not read memo header. %w", err)
+		}
+		block = binary.BigEndian.Uint32(intBuf)
+		binary.BigEndian.PutUint32(intBuf, block+uint32(blocks))
+		if _, err := w.WriteAt(intBuf, 0); err != nil {
+			return fmt.Errorf("Could not write memo header. %w", err)
+		}
+		// Fill the whole block, so the file always ends on a block boundary
+		buf = make([]byte, blocks*blockSize)
+	}
+	binary.BigEndian.PutUint32(buf[0:], memoType)
+	binary.BigEndian.PutUint32(buf[4:], uint32(len(data)))
+	copy(buf[8:], data)
+	if _, err := w.WriteAt(buf, int64(block)*blockSize); err != nil {
+		return fmt.Errorf("Could not write memo block %d. %w", block, err)
+	}
+	putMemoBlock(b, block)
+	return nil
+}
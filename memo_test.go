@@ -0,0 +1,92 @@
+package dbf
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// createDBTTable creates a table of the given dBase type with one record whose memo points to block 1
+func createDBTTable(t *testing.T, typ Type, dbt []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "dbase.dbf")
+	schema := Schema{Type: TypeFoxPro2Memo, Fields: []Field{
+		{Name: "NAME", Type: 'C', Length: 10},
+		{Name: "NOTES", Type: 'M'},
+	}}
+	if err := Create(path, schema, charmap.Windows1252.NewEncoder()); err != nil {
+		t.Fatal(err)
+	}
+	tbl := openTestTableReadWrite(t, path)
+	if _, err := tbl.Append(map[string]interface{}{"NAME": "dBase"}); err != nil {
+		t.Fatal(err)
+	}
+	headerSize := int(tbl.Header().HeaderSize)
+	tbl.Close()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[0] = byte(typ)
+	copy(b[headerSize+11:], "         1")
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(strings.TrimSuffix(path, ".dbf") + ".fpt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(strings.TrimSuffix(path, ".dbf")+".dbt", dbt, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readDBTMemo(t *testing.T, path string) interface{} {
+	t.Helper()
+	tbl, err := Open(path, charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+	m, _ := recordMap(t, tbl, 0)
+	return m["NOTES"]
+}
+
+func TestDBaseIIIMemo(t *testing.T) {
+	text := strings.Repeat("dBase III memo ", 50) // spans two blocks
+	dbt := make([]byte, dbtBlockSize)
+	binary.LittleEndian.PutUint32(dbt, 4)
+	dbt = append(dbt, text+"\x1A\x1A"...)
+
+	if v := readDBTMemo(t, createDBTTable(t, TypeFoxBasePlusDBaseIIIMemo, dbt)); v != text {
+		t.Fatalf("Unexpected memo %q", v)
+	}
+}
+
+func TestDBaseIVMemo(t *testing.T) {
+	const blockSize = 64
+	text := "dBase IV memo with \x1A inside"
+	dbt := make([]byte, blockSize)
+	binary.LittleEndian.PutUint32(dbt, 2)
+	binary.LittleEndian.PutUint16(dbt[20:], blockSize)
+	dbt = append(dbt, dbt4Signature...)
+	dbt = binary.LittleEndian.AppendUint32(dbt, uint32(8+len(text)))
+	dbt = append(dbt, text...)
+
+	path := createDBTTable(t, TypeDBaseIVMemo, dbt)
+	if v := readDBTMemo(t, path); v != text {
+		t.Fatalf("Unexpected memo %q", v)
+	}
+
+	tbl := openTestTableReadWrite(t, path)
+	defer tbl.Close()
+	if err := tbl.Update(0, map[string]interface{}{"NOTES": "new"}); !errors.Is(err, ErrUnsupportedMemoFormat) {
+		t.Fatalf("Expected ErrUnsupportedMemoFormat, got %v", err)
+	}
+}
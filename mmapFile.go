@@ -1,29 +1,51 @@
 package dbf
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
-
-	"golang.org/x/exp/mmap"
 )
 
 type mmapFile struct {
-	file     *os.File
-	readerAt *mmap.ReaderAt
-	offset   int64
+	file   *os.File
+	data   []byte
+	offset int64
 }
 
-func newMmapFile(f *os.File) *mmapFile {
-	ra, err := mmap.Open(f.Name())
+func newMmapFile(f *os.File) (*mmapFile, error) {
+	stat, err := f.Stat()
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	size := stat.Size()
+	if size <= 0 || size != int64(int(size)) {
+		return nil, fmt.Errorf("Can not map %q with a size of %d", f.Name(), size)
+	}
+	data, err := mapFile(f, int(size))
+	if err != nil {
+		return nil, err
 	}
 	return &mmapFile{
-		file:     f,
-		readerAt: ra,
+		file: f,
+		data: data,
+	}, nil
+}
+
+// openMmap memory maps the file at `path`.
+// Files that can not be mapped, like empty files, are read with regular file I/O.
+func openMmap(path string) (file, error) {
+	osF, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := newMmapFile(osF)
+	if err != nil {
+		return osF, nil
 	}
+	return f, nil
 }
+
 func (f *mmapFile) Stat() (os.FileInfo, error) {
 	return f.file.Stat()
 }
@@ -32,7 +54,12 @@ func (f *mmapFile) Name() string {
 }
 func (f *mmapFile) Close() error {
 	f.file.Close()
-	return f.readerAt.Close()
+	if f.data == nil {
+		return nil
+	}
+	data := f.data
+	f.data = nil
+	return unmapFile(data)
 }
 func (f *mmapFile) Read(b []byte) (int, error) {
 	n, err := f.ReadAt(b, f.offset)
@@ -43,7 +70,22 @@ func (f *mmapFile) Read(b []byte) (int, error) {
 	return n, nil
 }
 func (f *mmapFile) ReadAt(b []byte, offset int64) (int, error) {
-	return f.readerAt.ReadAt(b, offset)
+	if offset < 0 || offset > int64(len(f.data)) {
+		return 0, errors.New("Invalid offset")
+	}
+	n := copy(b, f.data[offset:])
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// slice returns `n` bytes at `offset` from the mapped memory without copying them, or nil if they are out of range
+func (f *mmapFile) slice(offset, n int64) []byte {
+	if offset < 0 || n < 0 || offset+n > int64(len(f.data)) {
+		return nil
+	}
+	return f.data[offset : offset+n : offset+n]
 }
 func (f *mmapFile) Seek(offset int64, whence int) (int64, error) {
 	if whence == io.SeekStart {
@@ -51,13 +93,13 @@ func (f *mmapFile) Seek(offset int64, whence int) (int64, error) {
 	} else if whence == io.SeekCurrent {
 		f.offset += offset
 	} else if whence == io.SeekEnd {
-		f.offset = int64(f.readerAt.Len() - int(offset))
+		f.offset = int64(len(f.data) - int(offset))
 	} else {
 		return f.offset, fmt.Errorf("Invalid parameter 'whence'")
 	}
 	if f.offset < 0 {
 		return f.offset, fmt.Errorf("Can not seek beyond BOF")
-	} else if f.offset >= int64(f.readerAt.Len()) {
+	} else if f.offset >= int64(len(f.data)) {
 		return f.offset, fmt.Errorf("Can not seek beyond EOF")
 	}
 	return f.offset, nil
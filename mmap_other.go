@@ -0,0 +1,17 @@
+//go:build !unix && !windows
+
+package dbf
+
+import (
+	"errors"
+	"os"
+)
+
+// mapFile is not supported on this platform, files are read with regular file I/O
+func mapFile(f *os.File, size int) ([]byte, error) {
+	return nil, errors.New("Memory mapping is not supported")
+}
+
+func unmapFile(data []byte) error {
+	return nil
+}
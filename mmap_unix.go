@@ -0,0 +1,17 @@
+//go:build unix
+
+package dbf
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps the whole file read-only into memory
+func mapFile(f *os.File, size int) ([]byte, error) {
+	return syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+}
+
+func unmapFile(data []byte) error {
+	return syscall.Munmap(data)
+}
@@ -0,0 +1,82 @@
+package ntx
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Kirides/go-dbf/internal/keyvalue"
+)
+
+// EncodeKey converts a value into the key format of the index.
+// Character keys take a string or []byte that is already in the code page of the table.
+// Numeric keys ('N', 'F', 'I') take integers or floats, date keys ('D') a time.Time
+// and logical keys ('L') a bool.
+func (idx *Index) EncodeKey(v interface{}) ([]byte, error) {
+	key, err := idx.encodeValue(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) > idx.KeyLength {
+		return nil, fmt.Errorf("Key exceeds the key length of %d", idx.KeyLength)
+	}
+	return key, nil
+}
+
+func (idx *Index) encodeValue(v interface{}) ([]byte, error) {
+	switch idx.keyType {
+	case 'C':
+		switch s := v.(type) {
+		case string:
+			return []byte(s), nil
+		case []byte:
+			return s, nil
+		}
+	case 'N', 'F', 'I':
+		if f, ok := keyvalue.Float(v); ok {
+			return numericKey(f, idx.KeyLength, idx.Decimals)
+		}
+	case 'D':
+		if d, ok := v.(time.Time); ok {
+			if d.IsZero() {
+				return bytes.Repeat([]byte{' '}, 8), nil
+			}
+			return []byte(d.Format("20060102")), nil
+		}
+	case 'L':
+		if b, ok := v.(bool); ok {
+			if b {
+				return []byte{'T'}, nil
+			}
+			return []byte{'F'}, nil
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported key type %q", idx.keyType)
+	}
+	return nil, fmt.Errorf("Invalid key %v (%T) for key type %q", v, v, idx.keyType)
+}
+
+// numericKey formats `f` like STR(f, length, decimals) and makes it sortable the way Clipper does:
+// leading blanks become zeros and negative numbers have their digits inverted.
+func numericKey(f float64, length, decimals int) ([]byte, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("Invalid numeric key %v", f)
+	}
+	s := strconv.FormatFloat(f, 'f', decimals, 64)
+	if len(s) > length {
+		return nil, fmt.Errorf("Numeric key %s exceeds the key length of %d", s, length)
+	}
+	key := bytes.Repeat([]byte{'0'}, length)
+	copy(key[length-len(s):], s)
+	if i := bytes.IndexByte(key, '-'); i >= 0 {
+		key[i] = '0'
+		for j, c := range key {
+			if c >= '0' && c <= '9' {
+				key[j] = '0' - (c - '0') - 4
+			}
+		}
+	}
+	return key, nil
+}
@@ -0,0 +1,238 @@
+// Package ntx reads Clipper and Harbour single order indexes (.NTX)
+package ntx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	pageSize    = 1024
+	itemHeader  = 8
+	maxExprSize = 256
+)
+
+// Header signature flags
+const (
+	flagDefault  = 0x0006
+	flagForItem  = 0x0001
+	flagCompound = 0x0080
+)
+
+var errStop = errors.New("stop")
+
+// Index is a single order index
+type Index struct {
+	// Name is the tag name Harbour stores in the header, empty for Clipper indexes
+	Name       string
+	KeyExpr    string
+	ForExpr    string
+	KeyLength  int
+	Decimals   int
+	Unique     bool
+	Descending bool
+
+	r        io.ReaderAt
+	closer   io.Closer
+	root     uint32
+	maxItems int
+	keyType  rune
+}
+
+// Open opens the index file at `path`
+func Open(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := NewIndex(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("Could not read index %q. %w", path, err)
+	}
+	idx.closer = f
+	return idx, nil
+}
+
+// NewIndex reads an index from `r`
+func NewIndex(r io.ReaderAt) (*Index, error) {
+	buf := make([]byte, pageSize)
+	if _, err := r.ReadAt(buf, 0); err != nil {
+		return nil, fmt.Errorf("Could not read index header. %w", err)
+	}
+	signature := binary.LittleEndian.Uint16(buf[0:])
+	if (signature & flagDefault) != flagDefault {
+		return nil, fmt.Errorf("Not an NTX index (signature 0x%04X)", signature)
+	}
+	if (signature & flagCompound) != 0 {
+		return nil, fmt.Errorf("Multi tag NTX indexes are not supported")
+	}
+	idx := &Index{
+		KeyExpr:    cString(buf[22 : 22+maxExprSize]),
+		KeyLength:  int(binary.LittleEndian.Uint16(buf[14:])),
+		Decimals:   int(binary.LittleEndian.Uint16(buf[16:])),
+		Unique:     buf[278] != 0,
+		Descending: buf[280] != 0,
+		r:          r,
+		root:       binary.LittleEndian.Uint32(buf[4:]),
+		maxItems:   int(binary.LittleEndian.Uint16(buf[18:])),
+		keyType:    'C',
+	}
+	if (signature & flagForItem) != 0 {
+		idx.ForExpr = cString(buf[282 : 282+maxExprSize])
+	}
+	idx.Name = cString(buf[538:550])
+	itemSize := int(binary.LittleEndian.Uint16(buf[12:]))
+	if idx.KeyLength <= 0 || itemSize != idx.KeyLength+itemHeader {
+		return nil, fmt.Errorf("Invalid key length %d", idx.KeyLength)
+	}
+	if idx.maxItems <= 0 || 2+2*(idx.maxItems+1)+(idx.maxItems+1)*itemSize > pageSize {
+		return nil, fmt.Errorf("Invalid number of keys per page %d", idx.maxItems)
+	}
+	return idx, nil
+}
+
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0x00); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+// Close closes the underlying file if the index was opened with Open
+func (idx *Index) Close() error {
+	if idx.closer != nil {
+		return idx.closer.Close()
+	}
+	return nil
+}
+
+// SetKeyType sets the type of the key expression's result, which is used by EncodeKey.
+// Character keys ('C') are the default.
+func (idx *Index) SetKeyType(typ rune) {
+	idx.keyType = typ
+}
+
+type page struct {
+	buf   []byte
+	count int
+}
+
+func (idx *Index) readPage(offset uint32) (*page, error) {
+	buf := make([]byte, pageSize)
+	if _, err := idx.r.ReadAt(buf, int64(offset)); err != nil {
+		return nil, fmt.Errorf("Could not read page at %d. %w", offset, err)
+	}
+	p := &page{buf: buf, count: int(binary.LittleEndian.Uint16(buf))}
+	if p.count > idx.maxItems {
+		return nil, fmt.Errorf("Invalid page at %d", offset)
+	}
+	for i := 0; i <= p.count; i++ {
+		if item := p.item(i); item < 2 || item+itemHeader+idx.KeyLength > pageSize {
+			return nil, fmt.Errorf("Invalid page at %d", offset)
+		}
+	}
+	return p, nil
+}
+
+// item returns the offset of the i-th item, the item after the last key only holds a child page
+func (p *page) item(i int) int {
+	return int(binary.LittleEndian.Uint16(p.buf[2+2*i:]))
+}
+
+func (p *page) child(i int) uint32 {
+	return binary.LittleEndian.Uint32(p.buf[p.item(i):])
+}
+
+func (p *page) recno(i int) uint32 {
+	return binary.LittleEndian.Uint32(p.buf[p.item(i)+4:])
+}
+
+func (p *page) key(i, length int) []byte {
+	offset := p.item(i) + itemHeader
+	return p.buf[offset : offset+length]
+}
+
+// compare compares the prefix of the stored key `k` with `key` in index order
+func (idx *Index) compare(k, key []byte) int {
+	if len(k) > len(key) {
+		k = k[:len(key)]
+	}
+	cmp := bytes.Compare(k, key)
+	if idx.Descending {
+		return -cmp
+	}
+	return cmp
+}
+
+// Walk visits the B-tree in key order. Clipper keeps keys in interior pages too, they are visited between their subtrees.
+// The record numbers stored from 1 are passed zero based, for dbf.Dbf.RecordAt.
+// The key points into the page buffer and has to be copied to keep it.
+func (idx *Index) Walk(walk func(key []byte, recno uint32) error) error {
+	return idx.Range(nil, nil, walk)
+}
+
+// Range walks the keys from `from` up to and including `to`, a nil bound is open.
+// Clipper stores all keys as text, numeric and date keys included, so bounds match by prefix like SEEK with SET EXACT OFF.
+// Descending indexes keep their keys in reverse order, `from` is the larger key then.
+func (idx *Index) Range(from, to []byte, walk func(key []byte, recno uint32) error) error {
+	err := idx.visit(idx.root, from, to, walk, 0)
+	if err == errStop {
+		return nil
+	}
+	return err
+}
+
+// visit walks the subtree at `offset` in order. It returns errStop after passing `to`.
+func (idx *Index) visit(offset uint32, from, to []byte, walk func(key []byte, recno uint32) error, depth int) error {
+	if offset == 0 {
+		return nil
+	}
+	if depth > 64 {
+		return fmt.Errorf("Invalid index tree at page %d", offset)
+	}
+	p, err := idx.readPage(offset)
+	if err != nil {
+		return err
+	}
+	i := 0
+	if from != nil {
+		// keys before `from` and their left subtrees can be skipped
+		for i < p.count && idx.compare(p.key(i, idx.KeyLength), from) < 0 {
+			i++
+		}
+	}
+	for ; i < p.count; i++ {
+		if err := idx.visit(p.child(i), from, to, walk, depth+1); err != nil {
+			return err
+		}
+		from = nil
+		key := p.key(i, idx.KeyLength)
+		if to != nil && idx.compare(key, to) > 0 {
+			return errStop
+		}
+		if err := walk(key, p.recno(i)-1); err != nil {
+			return err
+		}
+	}
+	return idx.visit(p.child(p.count), from, to, walk, depth+1)
+}
+
+// Seek returns the zero based record number of the first key that starts with `key`, like SEEK in Clipper
+func (idx *Index) Seek(key []byte) (uint32, bool, error) {
+	var recno uint32
+	found := false
+	err := idx.Range(key, key, func(_ []byte, r uint32) error {
+		recno = r
+		found = true
+		return errStop
+	})
+	if err == errStop {
+		err = nil
+	}
+	return recno, found, err
+}
@@ -0,0 +1,175 @@
+package ntx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+)
+
+type testItem struct {
+	child uint32
+	recno uint32
+	key   string
+}
+
+// buildIndex creates a two level index with keys "K00".."K19" stored for records 20..1,
+// the root holds every fifth key and the leaves hold the keys in between.
+// The last leaf is the rightmost child of the root.
+func buildIndex(t *testing.T, descending bool) []byte {
+	const keyLength = 3
+	buf := make([]byte, pageSize)
+	binary.LittleEndian.PutUint16(buf[0:], flagDefault)
+	binary.LittleEndian.PutUint32(buf[4:], pageSize)
+	binary.LittleEndian.PutUint16(buf[12:], keyLength+itemHeader)
+	binary.LittleEndian.PutUint16(buf[14:], keyLength)
+	binary.LittleEndian.PutUint16(buf[18:], 8)
+	binary.LittleEndian.PutUint16(buf[20:], 4)
+	copy(buf[22:], "CODE")
+	buf[278] = 1
+	if descending {
+		buf[280] = 1
+	}
+	copy(buf[538:], "BY_CODE")
+
+	keys := make([]string, 20)
+	for i := range keys {
+		n := i
+		if descending {
+			n = 19 - i
+		}
+		keys[i] = fmt.Sprintf("K%02d", n)
+	}
+	item := func(i int, child uint32) testItem {
+		return testItem{child: child, recno: uint32(20 - i), key: keys[i]}
+	}
+	var root []testItem
+	var leaves [][]testItem
+	for i := 0; i < len(keys); i += 5 {
+		last := i+5 >= len(keys)
+		leaf := []testItem{}
+		for j := i; j < len(keys) && (j < i+4 || last); j++ {
+			leaf = append(leaf, item(j, 0))
+		}
+		leaves = append(leaves, leaf)
+		if !last {
+			root = append(root, item(i+4, uint32(pageSize*(2+len(leaves)-1))))
+		}
+	}
+	rightmost := uint32(pageSize * (2 + len(leaves) - 1))
+	buf = append(buf, encodePage(t, root, rightmost)...)
+	for _, leaf := range leaves {
+		buf = append(buf, encodePage(t, leaf, 0)...)
+	}
+	return buf
+}
+
+func encodePage(t *testing.T, items []testItem, rightmost uint32) []byte {
+	p := make([]byte, pageSize)
+	binary.LittleEndian.PutUint16(p, uint16(len(items)))
+	offset := 2 + 2*9
+	items = append(items, testItem{child: rightmost})
+	for i, it := range items {
+		binary.LittleEndian.PutUint16(p[2+2*i:], uint16(offset))
+		binary.LittleEndian.PutUint32(p[offset:], it.child)
+		binary.LittleEndian.PutUint32(p[offset+4:], it.recno)
+		copy(p[offset+8:], it.key)
+		offset += 3 + itemHeader
+	}
+	if offset > pageSize {
+		t.Fatalf("Page overflow")
+	}
+	return p
+}
+
+func TestWalk(t *testing.T) {
+	idx, err := NewIndex(bytes.NewReader(buildIndex(t, false)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.KeyExpr != "CODE" || idx.Name != "BY_CODE" || idx.KeyLength != 3 || !idx.Unique || idx.Descending || idx.ForExpr != "" {
+		t.Errorf("Unexpected index %+v", idx)
+	}
+
+	i := 0
+	err = idx.Walk(func(key []byte, recno uint32) error {
+		if expected := fmt.Sprintf("K%02d", i); string(key) != expected || recno != uint32(19-i) {
+			t.Errorf("Entry %d: expected %s/%d, got %s/%d", i, expected, 19-i, key, recno)
+		}
+		i++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i != 20 {
+		t.Errorf("Expected 20 keys, got %d", i)
+	}
+}
+
+func TestRangeAndSeek(t *testing.T) {
+	for _, descending := range []bool{false, true} {
+		idx, err := NewIndex(bytes.NewReader(buildIndex(t, descending)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		from, to := "K03", "K11"
+		if descending {
+			from, to = to, from
+		}
+		var keys []string
+		err = idx.Range([]byte(from), []byte(to), func(key []byte, recno uint32) error {
+			keys = append(keys, string(key))
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 9 || keys[0] != from || keys[8] != to {
+			t.Errorf("Descending %v: unexpected range %v", descending, keys)
+		}
+
+		recno, found, err := idx.Seek([]byte("K1"))
+		if err != nil || !found {
+			t.Fatalf("Descending %v: expected to find K1. %v", descending, err)
+		}
+		var first string
+		idx.Range([]byte("K1"), nil, func(key []byte, _ uint32) error {
+			first = string(key)
+			return errStop
+		})
+		expected := "K10"
+		if descending {
+			expected = "K19"
+		}
+		if first != expected {
+			t.Errorf("Descending %v: expected seek to position on %s, got %s (recno %d)", descending, expected, first, recno)
+		}
+		if _, found, _ := idx.Seek([]byte("K2")); found {
+			t.Errorf("Descending %v: did not expect to find K2", descending)
+		}
+	}
+}
+
+func TestNumericKey(t *testing.T) {
+	idx := &Index{KeyLength: 6, Decimals: 1}
+	idx.SetKeyType('N')
+	values := []float64{-12.5, -1, 0, 0.5, 3, 120.2}
+	var prev []byte
+	for _, v := range values {
+		key, err := idx.EncodeKey(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prev != nil && bytes.Compare(prev, key) >= 0 {
+			t.Errorf("Expected key %q for %v to sort after %q", key, v, prev)
+		}
+		prev = key
+	}
+	if key, _ := idx.EncodeKey(3); string(key) != "0003.0" {
+		t.Errorf("Unexpected key %q", key)
+	}
+	if _, err := idx.EncodeKey(123456); err == nil {
+		t.Errorf("Expected an error for a key that is too long")
+	}
+}
@@ -0,0 +1,370 @@
+package dbf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Pack removes all records that are marked as deleted and reclaims unused memo blocks.
+//
+// The table is rebuilt into temporary files that replace the original files by renaming them.
+// The original files are kept as .BAK/.TBK backups, like FoxPro does.
+// If replacing the files fails part-way, the backups are restored, at the latest when the table is opened by OpenReadWrite.
+// Indexes are not rebuilt, they are closed and return ErrIndexOutdated afterwards.
+func (dbf *Dbf) Pack() error {
+	return dbf.rebuild(func(record []byte) bool {
+		return record[0] != 0x2A
+	})
+}
+
+// Zap removes all records from the table.
+// Files are replaced the same way as in Pack.
+func (dbf *Dbf) Zap() error {
+	return dbf.rebuild(func(record []byte) bool {
+		return false
+	})
+}
+
+// rebuild writes all records for which `keep` returns true into new files and replaces the table with them
+func (dbf *Dbf) rebuild(keep func(record []byte) bool) error {
+	if _, err := dbf.dbfWriter(); err != nil {
+		return err
+	}
+	if dbf.memoFile != nil && dbf.memoFormat != memoFPT {
+		return ErrUnsupportedMemoFormat
+	}
+	dbfPath := dbf.dbfFile.Name()
+	memoPath := ""
+	if dbf.memoFile != nil {
+		memoPath = dbf.memoFile.Name()
+	}
+
+	tmpDbf, err := os.CreateTemp(filepath.Dir(dbfPath), filepath.Base(dbfPath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpDbf.Name())
+	var tmpMemo *os.File
+	if memoPath != "" {
+		tmpMemo, err = os.CreateTemp(filepath.Dir(memoPath), filepath.Base(memoPath)+".*.tmp")
+		if err != nil {
+			tmpDbf.Close()
+			return err
+		}
+		defer os.Remove(tmpMemo.Name())
+	}
+
+	err = dbf.copyRecords(tmpDbf, tmpMemo, keep)
+	if tmpMemo != nil {
+		if cErr := closeSynced(tmpMemo); err == nil {
+			err = cErr
+		}
+	}
+	if cErr := closeSynced(tmpDbf); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return err
+	}
+
+	swaps := []fileSwap{{target: dbfPath, backup: backupPath(dbfPath, ".BAK"), tmp: tmpDbf.Name()}}
+	if memoPath != "" {
+		swaps = append(swaps, fileSwap{target: memoPath, backup: backupPath(memoPath, ".TBK"), tmp: tmpMemo.Name()})
+	}
+	for _, sw := range swaps {
+		if err := copyFile(sw.target, sw.backup); err != nil {
+			return fmt.Errorf("Could not create backup. %w", err)
+		}
+	}
+	// The journal marks the files as inconsistent until all of them have been replaced.
+	// OpenReadWrite restores the backups of a table with a journal, see recoverPack.
+	if err := writePackJournal(dbfPath, swaps); err != nil {
+		return fmt.Errorf("Could not write pack journal. %w", err)
+	}
+
+	if closeBeforeRename {
+		dbf.closeFiles()
+	}
+	for _, sw := range swaps {
+		if err := rename(sw.tmp, sw.target); err != nil {
+			return dbf.abortRebuild(dbfPath, err)
+		}
+	}
+	// all files are replaced, the table is consistent again
+	if err := os.Remove(packJournalPath(dbfPath)); err != nil {
+		return dbf.abortRebuild(dbfPath, err)
+	}
+	reopened, err := openWith(osStorage{writable: true}, dbfPath, dbf.decoder, nil)
+	if err != nil {
+		// the previous handles stay open, unless the platform required closing them
+		return fmt.Errorf("Could not reopen the packed table. %w", err)
+	}
+	dbf.closeFiles()
+	dbf.dbfFile = reopened.dbfFile
+	dbf.memoFile = reopened.memoFile
+	dbf.header = reopened.header
+	if dbf.header.HasCDX() || dbf.header.HasMDX() {
+		dbf.indexMu.Lock()
+		dbf.closeIndexes()
+		dbf.indexOutdated = true
+		dbf.indexMu.Unlock()
+	}
+	return nil
+}
+
+// closeFiles closes the table and memo file without resetting the table
+func (dbf *Dbf) closeFiles() {
+	if dbf.dbfFile != nil {
+		dbf.dbfFile.Close()
+		dbf.dbfFile = nil
+	}
+	if dbf.memoFile != nil {
+		dbf.memoFile.Close()
+		dbf.memoFile = nil
+	}
+}
+
+// abortRebuild restores the original files after replacing them failed and reopens them
+func (dbf *Dbf) abortRebuild(dbfPath string, cause error) error {
+	if err := recoverPack(dbfPath); err != nil {
+		return fmt.Errorf("%w. Could not restore the backups, they are restored when the table is opened again. %v", cause, err)
+	}
+	restored, err := openWith(osStorage{writable: true}, dbfPath, dbf.decoder, nil)
+	if err != nil {
+		// keep the previous handles, if they are still open
+		return fmt.Errorf("%w. Could not reopen the table. %v", cause, err)
+	}
+	dbf.closeFiles()
+	dbf.dbfFile = restored.dbfFile
+	dbf.memoFile = restored.memoFile
+	dbf.header = restored.header
+	return cause
+}
+
+// fileSwap replaces `target` by `tmp`, `backup` holds a copy of the original
+type fileSwap struct {
+	target string
+	backup string
+	tmp    string
+}
+
+// rename is replaced in tests to simulate failures
+var rename = os.Rename
+
+// closeBeforeRename is set on platforms where open files can not be replaced
+var closeBeforeRename = runtime.GOOS == "windows"
+
+// packJournalPath returns the path of the journal that is written while a table is packed
+func packJournalPath(dbfPath string) string {
+	return dbfPath + ".pack"
+}
+
+// writePackJournal lists the backups and the files they belong to, relative to the table
+func writePackJournal(dbfPath string, swaps []fileSwap) error {
+	var b strings.Builder
+	for _, sw := range swaps {
+		fmt.Fprintf(&b, "%s\t%s\n", filepath.Base(sw.backup), filepath.Base(sw.target))
+	}
+	f, err := os.Create(packJournalPath(dbfPath))
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(b.String()); err != nil {
+		f.Close()
+		return err
+	}
+	return closeSynced(f)
+}
+
+// recoverPack restores the backups of a table whose Pack or Zap did not finish.
+// Tables without a journal are left alone.
+func recoverPack(dbfPath string) error {
+	journal, err := os.ReadFile(packJournalPath(dbfPath))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	dir := filepath.Dir(dbfPath)
+	for _, line := range strings.Split(strings.TrimSpace(string(journal)), "\n") {
+		backup, target, ok := strings.Cut(line, "\t")
+		if !ok {
+			return fmt.Errorf("Invalid pack journal %q", packJournalPath(dbfPath))
+		}
+		if err := copyFile(filepath.Join(dir, backup), filepath.Join(dir, target)); err != nil {
+			return err
+		}
+	}
+	return os.Remove(packJournalPath(dbfPath))
+}
+
+func (dbf *Dbf) copyRecords(dst *os.File, memoDst *os.File, keep func(record []byte) bool) error {
+	header := make([]byte, dbf.header.HeaderSize)
+	if _, err := dbf.dbfFile.ReadAt(header, 0); err != nil {
+		return fmt.Errorf("Could not read header. %w", err)
+	}
+
+	var memo *memoCopier
+	if memoDst != nil {
+		var err error
+		if memo, err = newMemoCopier(dbf, memoDst); err != nil {
+			return err
+		}
+	}
+
+	if _, err := dst.Seek(int64(len(header)), io.SeekStart); err != nil {
+		return err
+	}
+	w := bufio.NewWriter(dst)
+	record := make([]byte, dbf.header.RecordLength)
+	count := uint32(0)
+	for recno := uint32(0); recno < dbf.header.RecordCount; recno++ {
+		if _, err := dbf.dbfFile.ReadAt(record, dbf.recordOffset(recno)); err != nil {
+			return fmt.Errorf("Could not read record %d. %w", recno, err)
+		}
+		if !keep(record) {
+			continue
+		}
+		if memo != nil {
+			for i := range dbf.fields {
+				f := &dbf.fields[i]
+				if !isMemoType(f.Type) {
+					continue
+				}
+				if err := memo.copy(record[f.Displacement : f.Displacement+uint32(f.Length)]); err != nil {
+					return fmt.Errorf("Could not copy memo of record %d. %w", recno, err)
+				}
+			}
+		}
+		if _, err := w.Write(record); err != nil {
+			return err
+		}
+		count++
+	}
+	if err := w.WriteByte(eofMarker); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	h := dbf.header
+	h.RecordCount = count
+	h.setLastModified(time.Now())
+	header[1], header[2], header[3] = h.ModYear, h.ModMonth, h.ModDay
+	binary.LittleEndian.PutUint32(header[4:], count)
+	if _, err := dst.WriteAt(header, 0); err != nil {
+		return err
+	}
+	if memo != nil {
+		return memo.finish()
+	}
+	return nil
+}
+
+// memoCopier appends memo blocks of the source table to a new memo file
+type memoCopier struct {
+	src       file
+	dst       *os.File
+	blockSize int64
+	next      uint32
+	buf       []byte
+}
+
+func newMemoCopier(dbf *Dbf, dst *os.File) (*memoCopier, error) {
+	header := make([]byte, memoHeaderSize)
+	if _, err := dbf.memoFile.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("Could not read memo header. %w", err)
+	}
+	if _, err := dst.WriteAt(header, 0); err != nil {
+		return nil, err
+	}
+	return &memoCopier{
+		src:       dbf.memoFile,
+		dst:       dst,
+		blockSize: dbf.memoBlockSize,
+		next:      uint32((memoHeaderSize + dbf.memoBlockSize - 1) / dbf.memoBlockSize),
+	}, nil
+}
+
+// copy copies the memo the field `b` points to and updates the pointer
+func (m *memoCopier) copy(b []byte) error {
+	block := memoBlock(b)
+	if block == 0 {
+		return nil
+	}
+	head := make([]byte, 8)
+	if _, err := m.src.ReadAt(head, int64(block)*m.blockSize); err != nil {
+		return err
+	}
+	size := int64(8 + binary.BigEndian.Uint32(head[4:]))
+	blocks := (size + m.blockSize - 1) / m.blockSize
+	if int64(cap(m.buf)) < blocks*m.blockSize {
+		m.buf = make([]byte, blocks*m.blockSize)
+	}
+	buf := m.buf[:blocks*m.blockSize]
+	for i := size; i < int64(len(buf)); i++ {
+		buf[i] = 0x00
+	}
+	if _, err := m.src.ReadAt(buf[:size], int64(block)*m.blockSize); err != nil {
+		return err
+	}
+	if _, err := m.dst.WriteAt(buf, int64(m.next)*m.blockSize); err != nil {
+		return err
+	}
+	putMemoBlock(b, m.next)
+	m.next += uint32(blocks)
+	return nil
+}
+
+// finish writes the next free block into the memo header
+func (m *memoCopier) finish() error {
+	intBuf := make([]byte, 4)
+	binary.BigEndian.PutUint32(intBuf, m.next)
+	if _, err := m.dst.WriteAt(intBuf, 0); err != nil {
+		return err
+	}
+	// An empty memo file still needs its full header
+	return m.dst.Truncate(int64(m.next) * m.blockSize)
+}
+
+func closeSynced(f *os.File) error {
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// backupPath returns the path of the backup with the extension `ext` next to `path`
+func backupPath(path, ext string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ext
+}
+
+// copyFile copies the file `src` to `dst`
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return closeSynced(out)
+}
@@ -0,0 +1,219 @@
+package dbf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestPack(t *testing.T) {
+	path := createTestTable(t)
+	tbl := openTestTableReadWrite(t, path)
+	defer tbl.Close()
+
+	for i := 0; i < 5; i++ {
+		_, err := tbl.Append(map[string]interface{}{
+			"ID":    i,
+			"NOTES": fmt.Sprintf("memo of record %d", i),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	memoPath := tbl.memoFile.Name()
+	memoSizeBefore := fileSize(t, memoPath)
+	if err := tbl.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := tbl.Delete(3); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tbl.Pack(); err != nil {
+		t.Fatalf("Could not pack table. %v", err)
+	}
+	if tbl.Header().RecordCount != 3 || tbl.CalculatedRecordCount() != 3 {
+		t.Fatalf("Expected 3 records, got %d", tbl.Header().RecordCount)
+	}
+	if fileSize(t, memoPath) >= memoSizeBefore {
+		t.Errorf("Memo file was not compacted")
+	}
+	for _, ext := range []string{".BAK", ".TBK"} {
+		if _, err := os.Stat(filepath.Join(filepath.Dir(path), "write"+ext)); err != nil {
+			t.Errorf("Missing backup %s. %v", ext, err)
+		}
+	}
+
+	for recno, id := range []int32{0, 2, 4} {
+		m, deleted := recordMap(t, tbl, uint32(recno))
+		if deleted || m["ID"] != id || m["NOTES"] != fmt.Sprintf("memo of record %d", id) {
+			t.Errorf("Unexpected record %d: %v", recno, m)
+		}
+	}
+
+	// The table stays writable after packing
+	if _, err := tbl.Append(map[string]interface{}{"ID": 5, "NOTES": "after pack"}); err != nil {
+		t.Fatal(err)
+	}
+	if m, _ := recordMap(t, tbl, 3); m["NOTES"] != "after pack" {
+		t.Errorf("Unexpected record after pack: %v", m)
+	}
+}
+
+func TestZap(t *testing.T) {
+	path := createTestTable(t)
+	tbl := openTestTableReadWrite(t, path)
+	defer tbl.Close()
+
+	if _, err := tbl.Append(map[string]interface{}{"ID": 1, "NOTES": "memo"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tbl.Zap(); err != nil {
+		t.Fatalf("Could not zap table. %v", err)
+	}
+	if tbl.Header().RecordCount != 0 || tbl.CalculatedRecordCount() != 0 {
+		t.Fatalf("Expected an empty table, got %d records", tbl.Header().RecordCount)
+	}
+	if size := fileSize(t, tbl.memoFile.Name()); size != memoHeaderSize {
+		t.Errorf("Expected an empty memo file, got %d bytes", size)
+	}
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+	stat, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stat.Size()
+}
+
+func TestPackRenameFailure(t *testing.T) {
+	path := createTestTable(t)
+	tbl := openTestTableReadWrite(t, path)
+	defer tbl.Close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := tbl.Append(map[string]interface{}{"ID": i, "NOTES": fmt.Sprintf("memo of record %d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tbl.Delete(0); err != nil {
+		t.Fatal(err)
+	}
+
+	// The table is replaced first, replacing the memo fails
+	renamed := 0
+	rename = func(from, to string) error {
+		if renamed++; renamed == 2 {
+			return errors.New("rename failed")
+		}
+		return os.Rename(from, to)
+	}
+	defer func() { rename = os.Rename }()
+
+	if err := tbl.Pack(); err == nil {
+		t.Fatal("Expected the rename error")
+	}
+	if _, err := os.Stat(packJournalPath(path)); !os.IsNotExist(err) {
+		t.Errorf("Expected the journal to be removed, got %v", err)
+	}
+	check := func(tbl *Dbf) {
+		t.Helper()
+		if tbl.Header().RecordCount != 3 {
+			t.Fatalf("Expected the unpacked table, got %d records", tbl.Header().RecordCount)
+		}
+		for recno := uint32(0); recno < 3; recno++ {
+			if m, _ := recordMap(t, tbl, recno); m["NOTES"] != fmt.Sprintf("memo of record %d", recno) {
+				t.Errorf("Unexpected record %d: %v", recno, m)
+			}
+		}
+	}
+	check(tbl)
+
+	reopened, err := Open(path, charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	check(reopened)
+}
+
+func TestPackRecoverOnOpen(t *testing.T) {
+	path := createTestTable(t)
+	tbl := openTestTableReadWrite(t, path)
+	if _, err := tbl.Append(map[string]interface{}{"ID": 1, "NOTES": "original memo"}); err != nil {
+		t.Fatal(err)
+	}
+	memoPath := tbl.memoFile.Name()
+	tbl.Close()
+
+	// Simulates a crash after the memo file was replaced
+	swaps := []fileSwap{{target: path, backup: backupPath(path, ".BAK")}, {target: memoPath, backup: backupPath(memoPath, ".TBK")}}
+	for _, sw := range swaps {
+		if err := copyFile(sw.target, sw.backup); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writePackJournal(path, swaps); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(memoPath, make([]byte, memoHeaderSize), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// readers leave the files alone, the pack may still be running in another process
+	tbl, err := Open(path, charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tbl.Close()
+	if _, err := os.Stat(packJournalPath(path)); err != nil {
+		t.Fatalf("Expected the journal to be kept by readers, got %v", err)
+	}
+
+	tbl = openTestTableReadWrite(t, path)
+	defer tbl.Close()
+	if m, _ := recordMap(t, tbl, 0); m["NOTES"] != "original memo" {
+		t.Errorf("Expected the restored memo, got %v", m)
+	}
+	if _, err := os.Stat(packJournalPath(path)); !os.IsNotExist(err) {
+		t.Errorf("Expected the journal to be removed, got %v", err)
+	}
+}
+
+func TestPackOutdatesIndexes(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"contacts.dbf", "contacts.FPT", "contacts.cdx"} {
+		if err := copyFile(filepath.Join("test", name), filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tbl := openTestTableReadWrite(t, filepath.Join(dir, "contacts.dbf"))
+	defer tbl.Close()
+	if err := tbl.SetOrder("BY_NAME"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tbl.Delete(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := tbl.Pack(); err != nil {
+		t.Fatal(err)
+	}
+	if tbl.cdx != nil || tbl.Order() != "" {
+		t.Errorf("Expected the index to be closed")
+	}
+	if _, _, err := tbl.Seek("A"); err != ErrIndexOutdated {
+		t.Errorf("Expected ErrIndexOutdated from Seek, got %v", err)
+	}
+	if err := tbl.ScanOrdered("BY_NAME", nil, nil, func(r *Record) error { return nil }, 0); err != ErrIndexOutdated {
+		t.Errorf("Expected ErrIndexOutdated from ScanOrdered, got %v", err)
+	}
+	if _, err := tbl.Indexes(); err != ErrIndexOutdated {
+		t.Errorf("Expected ErrIndexOutdated from Indexes, got %v", err)
+	}
+}
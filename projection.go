@@ -0,0 +1,89 @@
+package dbf
+
+import (
+	"fmt"
+)
+
+// Projection is a subset of the fields of a table.
+// Projected reads only decode these fields, memos of other fields are not read at all.
+type Projection struct {
+	dbf    *Dbf
+	fields []*Field
+}
+
+// Project returns a projection of the fields `names` in the given order. Names are case insensitive.
+func (dbf *Dbf) Project(names ...string) (*Projection, error) {
+	p := &Projection{dbf: dbf, fields: make([]*Field, 0, len(names))}
+	for _, name := range names {
+		i := dbf.fieldIndex(name)
+		if i < 0 {
+			return nil, fmt.Errorf("Field not found %q", name)
+		}
+		p.fields = append(p.fields, &dbf.fields[i])
+	}
+	return p, nil
+}
+
+// ProjectAt returns a projection of the fields at `indexes` in the given order
+func (dbf *Dbf) ProjectAt(indexes ...int) (*Projection, error) {
+	p := &Projection{dbf: dbf, fields: make([]*Field, 0, len(indexes))}
+	for _, i := range indexes {
+		if i < 0 || i >= len(dbf.fields) {
+			return nil, fmt.Errorf("Field index %d out of range", i)
+		}
+		p.fields = append(p.fields, &dbf.fields[i])
+	}
+	return p, nil
+}
+
+// Names returns the names of the projected fields
+func (p *Projection) Names() []string {
+	names := make([]string, len(p.fields))
+	for i, f := range p.fields {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// Project parses the projected fields of the record into `dst` and returns it.
+// dst is grown if it is too small, NULL values are nil.
+func (r *Record) Project(p *Projection, dst []interface{}) ([]interface{}, error) {
+	if p.dbf != r.dbf {
+		return nil, fmt.Errorf("Projection does not belong to this table")
+	}
+	if err := r.parse(); err != nil {
+		return nil, err
+	}
+	if cap(dst) < len(p.fields) {
+		dst = make([]interface{}, len(p.fields))
+	}
+	dst = dst[:len(p.fields)]
+	for i, f := range p.fields {
+		if r.isNull(f) {
+			dst[i] = nil
+			continue
+		}
+		v, ok, err := r.parseField(f)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			v = nil
+		}
+		dst[i] = v
+	}
+	return dst, nil
+}
+
+// ScanProjected walks the entire table like Scan and passes the values of the projected fields to walk.
+// The values are only valid within the current call of walk.
+func (dbf *Dbf) ScanProjected(p *Projection, walk func(r *Record, values []interface{}) error, options ParseOption) error {
+	values := make([]interface{}, len(p.fields))
+	return dbf.Scan(func(r *Record) error {
+		var err error
+		if values, err = r.Project(p, values); err != nil {
+			return err
+		}
+		return walk(r, values)
+	}, options)
+}
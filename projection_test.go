@@ -0,0 +1,97 @@
+package dbf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// countingReaderAt counts the calls of ReadAt
+type countingReaderAt struct {
+	*bytes.Reader
+	reads atomic.Int32
+}
+
+func (c *countingReaderAt) ReadAt(b []byte, off int64) (int, error) {
+	c.reads.Add(1)
+	return c.Reader.ReadAt(b, off)
+}
+
+func TestScanProjected(t *testing.T) {
+	path := createTestTable(t)
+	tbl := openTestTableReadWrite(t, path)
+	for i := 0; i < 3; i++ {
+		_, err := tbl.Append(map[string]interface{}{"ID": i, "NAME": fmt.Sprint("Name ", i), "NOTES": strings.Repeat("x", 100*i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	tbl.Close()
+
+	dbfBytes, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fptBytes, err := os.ReadFile(strings.TrimSuffix(path, ".dbf") + ".fpt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	memo := &countingReaderAt{Reader: bytes.NewReader(fptBytes)}
+	tbl, err = OpenReaderAt(bytes.NewReader(dbfBytes), int64(len(dbfBytes)), memo, int64(len(fptBytes)), charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+
+	p, err := tbl.Project("name", "id", "nick")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(p.Names()) != "[NAME ID NICK]" {
+		t.Errorf("Unexpected names %v", p.Names())
+	}
+	memo.reads.Store(0)
+	var rows []string
+	err = tbl.ScanProjected(p, func(r *Record, values []interface{}) error {
+		rows = append(rows, fmt.Sprint(values))
+		return nil
+	}, ParseTrimRight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(rows) != "[[Name 0 0 ] [Name 1 1 ] [Name 2 2 ]]" {
+		t.Errorf("Unexpected rows %v", rows)
+	}
+	if n := memo.reads.Load(); n != 0 {
+		t.Errorf("Expected no memo reads, got %d", n)
+	}
+
+	p, err = tbl.ProjectAt(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tbl.RecordAt(2, func(r *Record) {
+		values, err := r.Project(p, nil)
+		if err != nil || len(values) != 1 || values[0] != strings.Repeat("x", 200) {
+			t.Errorf("Unexpected memo %v %v", values, err)
+		}
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if memo.reads.Load() == 0 {
+		t.Errorf("Expected the memo to be read")
+	}
+
+	if _, err := tbl.Project("MISSING"); err == nil {
+		t.Errorf("Expected an error for an unknown field")
+	}
+	if _, err := tbl.ProjectAt(len(testSchema().Fields) + 1); err == nil {
+		t.Errorf("Expected an error for an index out of range")
+	}
+}
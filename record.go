@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
-	"io"
 	"math"
 	"strconv"
 	"sync"
@@ -53,10 +52,14 @@ type Record struct {
 	buffer       []byte
 	dbf          *Dbf
 	read         bool
+	err          error
 	parseOptions ParseOption
 
-	nullFlags uint64
+	nullFlags []byte
 	intBuf    [4]byte
+
+	// data holds the bytes of the record, either in buffer or in the mapped memory of the table
+	data []byte
 }
 
 func newRecord(dbf *Dbf, recno uint32, parseOptions ParseOption) *Record {
@@ -68,13 +71,43 @@ func newRecord(dbf *Dbf, recno uint32, parseOptions ParseOption) *Record {
 	}
 }
 
-// Deleted returns a bool that tells if a record is marked as deleted or not
+// Err returns the error of reading the record, if it was read
+func (r *Record) Err() error {
+	return r.err
+}
+
+// Deleted returns a bool that tells if a record is marked as deleted or not.
+// Records that could not be read are not deleted, see Err.
 func (r *Record) Deleted() bool {
-	if !r.read {
-		r.parse()
+	if r.parse() != nil {
+		return false
+	}
+
+	return r.data[0] == 0x2A
+}
+
+// Raw returns the bytes of the record, starting with the deletion flag.
+// Tables that are memory mapped return the mapped memory without copying it.
+// The slice is only valid within the current Scan or RecordAt and must not be modified.
+// Records that could not be read return nil, see Err.
+func (r *Record) Raw() []byte {
+	if r.parse() != nil {
+		return nil
 	}
+	return r.data
+}
 
-	return r.buffer[0] == 0x2A
+// RawField returns the bytes of the field `f` like Raw, without decoding them.
+// Memo fields return their block number, not the memo.
+func (r *Record) RawField(f Field) ([]byte, error) {
+	if err := r.parse(); err != nil {
+		return nil, err
+	}
+	end := f.Displacement + uint32(f.Length)
+	if f.Displacement == 0 || end > uint32(len(r.data)) {
+		return nil, fmt.Errorf("Field %q does not belong to this table", f.Name)
+	}
+	return r.data[f.Displacement:end:end], nil
 }
 
 // Recno returns the record number for the current record
@@ -82,25 +115,66 @@ func (r *Record) Recno() uint32 {
 	return r.recno
 }
 
-func (r *Record) parse() {
-	if r.read {
-		return
+// flag returns a bit of the _NullFlags field
+func (r *Record) flag(bit int) bool {
+	if bit < 0 || bit/8 >= len(r.nullFlags) {
+		return false
 	}
-	readAll(r.dbf.dbfFile, r.buffer[:r.dbf.header.RecordLength])
+	return (r.nullFlags[bit/8] & (1 << (bit % 8))) != 0
+}
 
-	if r.dbf.nullField != nil {
-		if r.dbf.nullField.Length == 1 {
-			r.nullFlags = uint64(r.buffer[r.dbf.nullField.Displacement])
+// isNull reports if the null flag of a nullable field is set
+func (r *Record) isNull(f *Field) bool {
+	return f.NullFieldIndex != -1 && r.flag(f.NullFieldIndex)
+}
+
+// varLength returns the used length of a varchar or varbinary field.
+// If the field is not filled completely its last byte holds the length.
+func (r *Record) varLength(f *Field) int {
+	if !r.flag(f.VarLengthSizeIndex) {
+		return int(f.Length)
+	}
+	n := int(r.data[f.Displacement+uint32(f.Length)-1])
+	if n > int(f.Length) {
+		return int(f.Length)
+	}
+	return n
+}
+
+// parse reads the record once and returns the error of reading it.
+// A record that could not be read completely is zeroed, so stale bytes of a previous record are never decoded.
+func (r *Record) parse() error {
+	if r.read {
+		return r.err
+	}
+	size := int64(r.dbf.header.RecordLength)
+	r.data = nil
+	r.err = nil
+	if s, ok := r.dbf.dbfFile.(slicer); ok {
+		r.data = s.slice(r.dbf.recordOffset(r.recno), size)
+	}
+	if r.data == nil {
+		r.data = r.buffer[:size]
+		if n, err := r.dbf.dbfFile.ReadAt(r.data, r.dbf.recordOffset(r.recno)); n < len(r.data) {
+			for i := range r.data {
+				r.data[i] = 0
+			}
+			r.err = fmt.Errorf("Could not read record %d: %w", r.recno, err)
 		}
 	}
 
+	if nf := r.dbf.nullField; nf != nil {
+		r.nullFlags = r.data[nf.Displacement : nf.Displacement+uint32(nf.Length)]
+	}
+
 	r.read = true
+	return r.err
 }
 
 // ToMap parses the record into a map[string]interface{}
 func (r *Record) ToMap() (map[string]interface{}, error) {
-	if !r.read {
-		r.parse()
+	if err := r.parse(); err != nil {
+		return nil, err
 	}
 	m := make(map[string]interface{})
 
@@ -109,11 +183,9 @@ func (r *Record) ToMap() (map[string]interface{}, error) {
 		if (f.Flags & FieldFlagSystem) != 0 {
 			continue
 		}
-		if f.NullFieldIndex != -1 {
-			if (r.nullFlags & (1 << f.NullFieldIndex)) != 0 {
-				m[f.Name] = nil
-				continue
-			}
+		if r.isNull(&f) {
+			m[f.Name] = nil
+			continue
 		}
 
 		v, ok, err := r.parseField(&f)
@@ -130,14 +202,18 @@ func (r *Record) ToMap() (map[string]interface{}, error) {
 
 // FieldAt returns a value for that specific field
 func (r *Record) FieldAt(fieldIndex int) (interface{}, error) {
-	if !r.read {
-		r.parse()
+	if err := r.parse(); err != nil {
+		return nil, err
 	}
 	if fieldIndex < 0 || fieldIndex >= len(r.dbf.fields) {
 		return nil, fmt.Errorf("FieldAt: Index out of range")
 	}
+	f := &r.dbf.fields[fieldIndex]
+	if r.isNull(f) {
+		return nil, nil
+	}
 
-	v, ok, err := r.parseField(&r.dbf.fields[fieldIndex])
+	v, ok, err := r.parseField(f)
 	if err != nil {
 		return nil, err
 	}
@@ -147,30 +223,33 @@ func (r *Record) FieldAt(fieldIndex int) (interface{}, error) {
 	return nil, fmt.Errorf("FieldAt: Error parsing value")
 }
 
-// Field returns a value for that specific field
+// Field returns a value for that specific field (Case insensitive)
 func (r *Record) Field(fieldName string) (interface{}, error) {
-	if !r.read {
-		r.parse()
+	i := r.dbf.fieldIndex(fieldName)
+	if i < 0 {
+		return nil, fmt.Errorf("Field not found %s", fieldName)
 	}
-	for i := 0; i < len(r.dbf.fields); i++ {
-		if r.dbf.fields[i].Name == fieldName {
-			v, ok, err := r.parseField(&r.dbf.fields[i])
-			if err != nil {
-				return nil, err
-			}
-			if ok {
-				return v, nil
-			}
-			break
-		}
+	if err := r.parse(); err != nil {
+		return nil, err
+	}
+	f := &r.dbf.fields[i]
+	if r.isNull(f) {
+		return nil, nil
 	}
-	return nil, fmt.Errorf("Field not found %s", fieldName)
+	v, ok, err := r.parseField(f)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("Field not found %s", fieldName)
+	}
+	return v, nil
 }
 
 // ToSlice parses the record into a []interface{}
 func (r *Record) ToSlice() ([]interface{}, error) {
-	if !r.read {
-		r.parse()
+	if err := r.parse(); err != nil {
+		return nil, err
 	}
 	m := make([]interface{}, len(r.dbf.fields))
 
@@ -179,11 +258,9 @@ func (r *Record) ToSlice() ([]interface{}, error) {
 		if (f.Flags & FieldFlagSystem) != 0 {
 			continue
 		}
-		if f.NullFieldIndex != -1 {
-			if (r.nullFlags & (1 << f.NullFieldIndex)) != 0 {
-				m[i] = nil
-				continue
-			}
+		if r.isNull(&f) {
+			m[i] = nil
+			continue
 		}
 		v, ok, err := r.parseField(&f)
 		if err != nil {
@@ -200,8 +277,8 @@ func (r *Record) ToSlice() ([]interface{}, error) {
 // WithSlice parses the record into a []interface{}
 // The slice is only valid within the current Scan
 func (r *Record) WithSlice(sf func([]interface{})) error {
-	if !r.read {
-		r.parse()
+	if err := r.parse(); err != nil {
+		return err
 	}
 	m := getSliceBuffer(len(r.dbf.fields))
 	defer func() {
@@ -212,11 +289,9 @@ func (r *Record) WithSlice(sf func([]interface{})) error {
 		if (f.Flags & FieldFlagSystem) != 0 {
 			continue
 		}
-		if f.NullFieldIndex != -1 {
-			if (r.nullFlags & (1 << f.NullFieldIndex)) != 0 {
-				m[i] = nil
-				continue
-			}
+		if r.isNull(&f) {
+			m[i] = nil
+			continue
 		}
 		v, ok, err := r.parseField(&f)
 		if err != nil {
@@ -236,20 +311,21 @@ func (r *Record) parseField(f *Field) (interface{}, bool, error) {
 
 	trimRight := (r.parseOptions & ParseTrimRight) != 0
 	switch f.Type {
-	case 'I':
-		return binary.LittleEndian.Uint32(r.buffer[f.Displacement : f.Displacement+uint32(f.Length)]), true, nil
+	case 'I', '+':
+		if r.dbf.header.Type.isDBase7() {
+			return dBase7Int(r.data[f.Displacement:]), true, nil
+		}
+		return int32(binary.LittleEndian.Uint32(r.data[f.Displacement : f.Displacement+uint32(f.Length)])), true, nil
 	case 'V':
-		// TODO: properly implement Varchar handling.
-		sizeFlag := (r.nullFlags & (1 << f.VarLengthSizeIndex)) != 0
-		vLen := f.Length
-		if sizeFlag {
-			vLen = r.buffer[int(f.Displacement+(uint32(f.Length-1)))]
+		if (f.Flags & FieldFlagBinary) != 0 {
+			return r.rawField(f), true, nil
 		}
-		tBuf := getBuffer(int(vLen) * 4)
+		vLen := r.varLength(f)
+		tBuf := getBuffer(vLen * 4)
 		defer func() {
 			putBuffer(tBuf)
 		}()
-		nDst, _, _ := r.dbf.decoder.Transform(tBuf, r.buffer[f.Displacement:f.Displacement+uint32(vLen)], true)
+		nDst, _, _ := r.dbf.decoder.Transform(tBuf, r.data[f.Displacement:f.Displacement+uint32(vLen)], true)
 
 		v := tBuf[:nDst]
 		if trimRight {
@@ -257,11 +333,14 @@ func (r *Record) parseField(f *Field) (interface{}, bool, error) {
 		}
 		return string(v), true, nil
 	case 'C':
+		if (f.Flags & FieldFlagBinary) != 0 {
+			return r.rawField(f), true, nil
+		}
 		tBuf := getBuffer(int(f.Length) * 4)
 		defer func() {
 			putBuffer(tBuf)
 		}()
-		nDst, _, _ := r.dbf.decoder.Transform(tBuf, r.buffer[f.Displacement:f.Displacement+uint32(f.Length)], true)
+		nDst, _, _ := r.dbf.decoder.Transform(tBuf, r.data[f.Displacement:f.Displacement+uint32(f.Length)], true)
 
 		v := tBuf[:nDst]
 		if trimRight {
@@ -269,74 +348,191 @@ func (r *Record) parseField(f *Field) (interface{}, bool, error) {
 		}
 		return string(v), true, nil
 	case 'D':
-		v, _ := parseDateBytesYYYYMMDD(r.buffer[f.Displacement : f.Displacement+uint32(f.Length)])
-		// v, _ := time.Parse("20060102", string(r.buffer[f.Displacement:f.Displacement+uint32(f.Length)]))
+		v, err := parseDateBytesYYYYMMDD(r.data[f.Displacement : f.Displacement+uint32(f.Length)])
+		if err != nil {
+			return nil, false, fmt.Errorf("Could not parse field %s. %w", f.Name, err)
+		}
 		return v, true, nil
 	case 'T':
-		return julianDateTimeToTime(binary.LittleEndian.Uint64(r.buffer[f.Displacement : f.Displacement+uint32(f.Length)])), true, nil
-	case 'N':
-		b := r.buffer[f.Displacement : f.Displacement+uint32(f.Length)]
-		idxSpace := bytes.LastIndexByte(b, 32)
-		if idxSpace >= 0 {
-			if idxSpace == int(f.Length-1) {
-				b = b[0:0]
-			} else {
-				b = b[idxSpace+1:]
+		return julianDateTimeToTime(binary.LittleEndian.Uint64(r.data[f.Displacement : f.Displacement+uint32(f.Length)])), true, nil
+	case 'N', 'F':
+		b := bytes.TrimSpace(r.data[f.Displacement : f.Displacement+uint32(f.Length)])
+		if f.DecimalCount == 0 {
+			v, err := parseIntBytes(b)
+			if err != nil {
+				return nil, false, fmt.Errorf("Could not parse field %s. %w", f.Name, err)
 			}
+			return v, true, nil
 		}
-		if f.DecimalCount == 0 {
-			if len(b) == 0 {
-				return int64(0), true, nil
+		if (r.parseOptions & ParseExactDecimals) != 0 {
+			if len(b) == 0 || isOverflowed(b) {
+				// blank and overflowed values are 0 like float64 values
+				return Decimal{scale: f.DecimalCount}, true, nil
 			}
-			v, _ := strutil.ParseUintBytes(b, 10, 64)
-			return int64(v), true, nil
+			v, err := parseDecimalBytes(b, f.DecimalCount)
+			if err != nil {
+				return nil, false, fmt.Errorf("Could not parse field %s. %w", f.Name, err)
+			}
+			return v, true, nil
 		}
-		if len(b) == 0 {
-			return float64(0), true, nil
+		v, err := parseFloatBytes(b)
+		if err != nil {
+			return nil, false, fmt.Errorf("Could not parse field %s. %w", f.Name, err)
 		}
-		v, _ := strconv.ParseFloat(string(b), 64)
 		return v, true, nil
 	case 'L':
-		v := r.buffer[f.Displacement]
+		v := r.data[f.Displacement]
 		if v != 32 && v > 0 {
 			return true, true, nil
 		}
 		return false, true, nil
-	case 'M':
-		offset := binary.LittleEndian.Uint32(r.buffer[f.Displacement : f.Displacement+uint32(f.Length)])
-		if offset == 0 {
-			return "", true, nil
+	case 'Y':
+		return Currency(binary.LittleEndian.Uint64(r.data[f.Displacement : f.Displacement+uint32(f.Length)])), true, nil
+	case 'O':
+		return dBase7Double(r.data[f.Displacement:]), true, nil
+	case '@':
+		return julianDateTimeToTime(dBase7Timestamp(r.data[f.Displacement : f.Displacement+8])), true, nil
+	case 'B':
+		if f.Length == 10 {
+			// dBase binary memo
+			v, err := r.rawMemo(f)
+			return v, err == nil, err
 		}
-		_, err := r.dbf.memoFile.Seek(4+int64(offset)*r.dbf.memoBlockSize, io.SeekStart)
-		if err != nil {
-			return nil, false, err
+		v := math.Float64frombits(binary.LittleEndian.Uint64(r.data[f.Displacement : f.Displacement+uint32(f.Length)]))
+		if (r.parseOptions&ParseExactDecimals) != 0 && !math.IsNaN(v) && !math.IsInf(v, 0) {
+			d, err := parseDecimalBytes(strconv.AppendFloat(nil, v, 'f', int(f.DecimalCount), 64), f.DecimalCount)
+			if err == nil {
+				return d, true, nil
+			}
+			// values that a Decimal can not hold stay float64
 		}
-
-		_, err = readAll(r.dbf.memoFile, r.intBuf[:])
+		return v, true, nil
+	case 'Q':
+		return r.rawField(f), true, nil
+	case 'M':
+		if (f.Flags & FieldFlagBinary) != 0 {
+			v, err := r.rawMemo(f)
+			return v, err == nil, err
+		}
+		memo, buf, err := r.readMemo(f)
 		if err != nil {
 			return nil, false, err
 		}
-		memoSize := int(binary.BigEndian.Uint32(r.intBuf[:]))
-		if memoSize == 0 {
+		if len(memo) == 0 {
 			return "", true, nil
 		}
-		memoBuffer := getBuffer(memoSize)
-		tMemoBuffer := getBuffer(memoSize * 13 / 10)
+		tMemoBuffer := getBuffer(len(memo) * 13 / 10)
 		defer func() {
-			putBuffer(memoBuffer)
+			putBuffer(buf)
 			putBuffer(tMemoBuffer)
 		}()
-		_, err = readAll(r.dbf.memoFile, memoBuffer[:memoSize])
-		if err != nil {
-			return nil, false, err
-		}
-		nDst, _, _ := r.dbf.decoder.Transform(tMemoBuffer, memoBuffer[:memoSize], true)
+		nDst, _, _ := r.dbf.decoder.Transform(tMemoBuffer, memo, true)
 		v := tMemoBuffer[:nDst]
 		return string(v), true, nil
+	case 'W', 'G':
+		v, err := r.rawMemo(f)
+		return v, err == nil, err
 	}
 	return nil, false, nil
 }
 
+// rawField returns a copy of the bytes of a field without any decoding.
+// Only the used part of varchar and varbinary fields is returned.
+func (r *Record) rawField(f *Field) []byte {
+	n := int(f.Length)
+	if f.Type == 'V' || f.Type == 'Q' {
+		n = r.varLength(f)
+	}
+	v := make([]byte, n)
+	copy(v, r.data[f.Displacement:])
+	return v
+}
+
+// rawMemo returns a copy of the memo data without any decoding
+func (r *Record) rawMemo(f *Field) ([]byte, error) {
+	memo, buf, err := r.readMemo(f)
+	if err != nil {
+		return nil, err
+	}
+	v := make([]byte, len(memo))
+	copy(v, memo)
+	if buf != nil {
+		putBuffer(buf)
+	}
+	return v, nil
+}
+
+// readMemo reads the data a memo field points to into a pooled buffer.
+// buf has to be returned with putBuffer unless it is nil.
+func (r *Record) readMemo(f *Field) (memo []byte, buf []byte, err error) {
+	offset := memoBlock(r.data[f.Displacement : f.Displacement+uint32(f.Length)])
+	if offset == 0 {
+		return nil, nil, nil
+	}
+	if r.dbf.memoFile == nil {
+		return nil, nil, ErrNoMemoFile
+	}
+	if r.dbf.memoFormat != memoFPT {
+		return r.dbf.readDBTMemo(offset)
+	}
+	pos := 4 + int64(offset)*r.dbf.memoBlockSize
+	_, err = r.dbf.memoFile.ReadAt(r.intBuf[:], pos)
+	if err != nil {
+		return nil, nil, err
+	}
+	memoSize := int(binary.BigEndian.Uint32(r.intBuf[:]))
+	if memoSize == 0 {
+		return nil, nil, nil
+	}
+	buf = getBuffer(memoSize)
+	_, err = r.dbf.memoFile.ReadAt(buf[:memoSize], pos+4)
+	if err != nil {
+		putBuffer(buf)
+		return nil, nil, err
+	}
+	return buf[:memoSize], buf, nil
+}
+
+// isOverflowed reports whether a numeric field holds the asterisks written for values that do not fit
+func isOverflowed(b []byte) bool {
+	return len(b) > 0 && len(bytes.Trim(b, "*")) == 0
+}
+
+// parseIntBytes parses the trimmed bytes of a numeric field without decimals.
+// Blank and overflowed values are 0.
+func parseIntBytes(b []byte) (int64, error) {
+	if len(b) == 0 || isOverflowed(b) {
+		return 0, nil
+	}
+	digits := b
+	neg := false
+	if digits[0] == '-' || digits[0] == '+' {
+		neg = digits[0] == '-'
+		digits = digits[1:]
+	}
+	v, err := strutil.ParseUintBytes(digits, 10, 63)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid number %q", b)
+	}
+	if neg {
+		return -int64(v), nil
+	}
+	return int64(v), nil
+}
+
+// parseFloatBytes parses the trimmed bytes of a numeric field with decimals.
+// Blank and overflowed values are 0.
+func parseFloatBytes(b []byte) (float64, error) {
+	if len(b) == 0 || isOverflowed(b) {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(string(b), 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid number %q", b)
+	}
+	return v, nil
+}
+
 var minimumDateTime = time.Date(0001, time.Month(1), 1, 0, 0, 0, 0, time.Local)
 
 // MinimumDateTime returns 0001-01-01T00:00:00 @ time.Local
@@ -376,6 +572,21 @@ func julianDateTimeToTime(dateTime uint64) time.Time {
 	return time.Date(int(j), time.Month(int(m)), int(d), hour, min, sec, 0, time.Local)
 }
 
+// timeToJulianDateTime is the inverse of julianDateTimeToTime
+func timeToJulianDateTime(t time.Time) uint64 {
+	if isEmptyDate(t) {
+		return 0
+	}
+	y, m, d := t.Date()
+	a := (14 - int(m)) / 12
+	y2 := y + 4800 - a
+	m2 := int(m) + 12*a - 3
+	jdn := d + (153*m2+2)/5 + 365*y2 + y2/4 - y2/100 + y2/400 - 32045
+
+	ms := t.Hour()*3600000 + t.Minute()*60000 + t.Second()*1000
+	return uint64(ms)<<32 | uint64(uint32(jdn))
+}
+
 var emptyDateBytes = []byte{32, 32, 32, 32, 32, 32, 32, 32}
 
 // parseDateBytesYYYYMMDD parses a simple yyyyMMdd format into local-time time.Time
@@ -384,6 +595,14 @@ func parseDateBytesYYYYMMDD(date []byte) (time.Time, error) {
 	if bytes.Equal(date, emptyDateBytes) {
 		return MinimumDateTime(), nil
 	}
+	if len(date) != 8 {
+		return time.Time{}, fmt.Errorf("Invalid date %q", date)
+	}
+	for _, c := range date {
+		if c < '0' || c > '9' {
+			return time.Time{}, fmt.Errorf("Invalid date %q", date)
+		}
+	}
 	year := (((int(date[0])-'0')*10+int(date[1])-'0')*10+int(date[2])-'0')*10 + int(date[3]) - '0'
 	month := time.Month((int(date[4])-'0')*10 + int(date[5]) - '0')
 	day := (int(date[6])-'0')*10 + int(date[7]) - '0'
@@ -0,0 +1,238 @@
+package dbf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding"
+)
+
+// storage opens the files that belong to a table
+type storage interface {
+	// open opens the file `name`
+	open(name string) (file, error)
+	// companion returns the name of the file next to `name` with the extension `ext`.
+	// The extension is matched case-insensitively, as tables usually come from case-insensitive filesystems.
+	companion(name, ext string) (string, bool)
+	// resolve returns the name of `rel`, relative to the directory of `name`
+	resolve(name, rel string) string
+}
+
+// osStorage opens files of the operating system
+type osStorage struct {
+	mmap     bool
+	writable bool
+}
+
+func (s osStorage) open(name string) (file, error) {
+	if s.writable {
+		return os.OpenFile(name, os.O_RDWR, 0)
+	}
+	if s.mmap {
+		return openMmap(name)
+	}
+	return os.Open(name)
+}
+
+func (s osStorage) companion(name, ext string) (string, bool) {
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	candidates := []string{base + strings.ToUpper(ext), base + strings.ToLower(ext)}
+	for _, c := range candidates {
+		if _, err := os.Stat(c); err == nil {
+			return c, true
+		}
+	}
+
+	dir := filepath.Dir(name)
+	if entries, err := os.ReadDir(dir); err == nil {
+		wanted := filepath.Base(base) + ext
+		for _, e := range entries {
+			if strings.EqualFold(e.Name(), wanted) {
+				return filepath.Join(dir, e.Name()), true
+			}
+		}
+	}
+	return candidates[0], false
+}
+
+func (s osStorage) resolve(name, rel string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		name = abs
+	}
+	return filepath.Join(filepath.Dir(name), rel)
+}
+
+// readOnly returns a storage that opens companion files like indexes or the DBC for reading only
+func readOnly(s storage) storage {
+	if o, ok := s.(osStorage); ok {
+		o.writable = false
+		return o
+	}
+	return s
+}
+
+// fsStorage opens files of an fs.FS
+type fsStorage struct {
+	fsys fs.FS
+}
+
+func (s fsStorage) open(name string) (file, error) {
+	f, err := s.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if ra, ok := f.(io.ReaderAt); ok {
+		return newReaderFile(name, ra, stat.Size(), f), nil
+	}
+	// Files without random access are read into memory
+	b, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("Could not read %q. %w", name, err)
+	}
+	return newReaderFile(name, bytes.NewReader(b), int64(len(b)), nil), nil
+}
+
+func (s fsStorage) companion(name, ext string) (string, bool) {
+	base := strings.TrimSuffix(name, path.Ext(name))
+	candidates := []string{base + strings.ToUpper(ext), base + strings.ToLower(ext)}
+	for _, c := range candidates {
+		if _, err := fs.Stat(s.fsys, c); err == nil {
+			return c, true
+		}
+	}
+
+	dir := path.Dir(name)
+	if entries, err := fs.ReadDir(s.fsys, dir); err == nil {
+		wanted := path.Base(base) + ext
+		for _, e := range entries {
+			if strings.EqualFold(e.Name(), wanted) {
+				return path.Join(dir, e.Name()), true
+			}
+		}
+	}
+	return candidates[0], false
+}
+
+func (s fsStorage) resolve(name, rel string) string {
+	// Backlinks are stored with Windows path separators
+	return path.Join(path.Dir(name), strings.ReplaceAll(rel, `\`, "/"))
+}
+
+// readerStorage holds a table and its memo file that were passed as io.ReaderAt
+type readerStorage struct {
+	dbf  file
+	memo file
+}
+
+func (s readerStorage) open(name string) (file, error) {
+	switch {
+	case name == s.dbf.Name():
+		return s.dbf, nil
+	case s.memo != nil && name == s.memo.Name():
+		return s.memo, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (s readerStorage) companion(name, ext string) (string, bool) {
+	switch strings.ToUpper(ext) {
+	case ".FPT", ".DBT", ".DCT":
+		if s.memo != nil {
+			return s.memo.Name(), true
+		}
+	}
+	return strings.TrimSuffix(name, path.Ext(name)) + ext, false
+}
+
+func (s readerStorage) resolve(name, rel string) string {
+	return rel
+}
+
+// readerFile reads a file through an io.ReaderAt
+type readerFile struct {
+	*io.SectionReader
+	name   string
+	closer io.Closer
+}
+
+func newReaderFile(name string, r io.ReaderAt, size int64, closer io.Closer) *readerFile {
+	return &readerFile{SectionReader: io.NewSectionReader(r, 0, size), name: name, closer: closer}
+}
+
+func (f *readerFile) Name() string {
+	return f.name
+}
+
+func (f *readerFile) Stat() (os.FileInfo, error) {
+	return fileInfo{name: path.Base(f.name), size: f.Size()}, nil
+}
+
+func (f *readerFile) Close() error {
+	if f.closer != nil {
+		return f.closer.Close()
+	}
+	return nil
+}
+
+// fileInfo describes files that are not backed by the operating system
+type fileInfo struct {
+	name string
+	size int64
+}
+
+func (fi fileInfo) Name() string       { return fi.name }
+func (fi fileInfo) Size() int64        { return fi.size }
+func (fi fileInfo) Mode() fs.FileMode  { return 0o444 }
+func (fi fileInfo) ModTime() time.Time { return time.Time{} }
+func (fi fileInfo) IsDir() bool        { return false }
+func (fi fileInfo) Sys() interface{}   { return nil }
+
+// OpenFile opens the specified DBF like Open, but reads it with regular file I/O instead of memory mapping it
+func OpenFile(path string, decoder *encoding.Decoder) (*Dbf, error) {
+	return openWith(osStorage{}, path, decoder, nil)
+}
+
+// OpenFS opens the DBF `name` of `fsys`, e.g. an embed.FS.
+// The memo file, indexes and the DBC are looked up in `fsys` as well.
+// A nil decoder detects the encoding from the header's code page mark.
+func OpenFS(fsys fs.FS, name string, decoder *encoding.Decoder) (*Dbf, error) {
+	return openWith(fsStorage{fsys: fsys}, name, decoder, nil)
+}
+
+// OpenReaderAt opens a DBF that is read from `r` with `size` bytes.
+// `memo` and `memoSize` provide the memo file, `memo` may be nil for tables without memo fields.
+// A nil decoder detects the encoding from the header's code page mark.
+func OpenReaderAt(r io.ReaderAt, size int64, memo io.ReaderAt, memoSize int64, decoder *encoding.Decoder) (*Dbf, error) {
+	if r == nil {
+		return nil, errors.New("Missing table data")
+	}
+	s := readerStorage{dbf: newReaderFile("table.dbf", r, size, nil)}
+	if memo != nil {
+		s.memo = newReaderFile("table.memo", memo, memoSize, nil)
+	}
+	return openWith(s, s.dbf.Name(), decoder, nil)
+}
+
+// OpenBytes opens a DBF held in memory. `memo` holds the memo file and may be nil.
+// A nil decoder detects the encoding from the header's code page mark.
+func OpenBytes(b []byte, memo []byte, decoder *encoding.Decoder) (*Dbf, error) {
+	var memoReader io.ReaderAt
+	if memo != nil {
+		memoReader = bytes.NewReader(memo)
+	}
+	return OpenReaderAt(bytes.NewReader(b), int64(len(b)), memoReader, int64(len(memo)), decoder)
+}
@@ -0,0 +1,107 @@
+package dbf
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// tableMaps reads all records of a table
+func tableMaps(t *testing.T, tbl *Dbf) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	err := tbl.Scan(func(r *Record) error {
+		m, err := r.ToMap()
+		records = append(records, m)
+		return err
+	}, ParseTrimRight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+func TestOpenBytes(t *testing.T) {
+	tbl, err := Open("test/contacts.dbf", charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+	expected := tableMaps(t, tbl)
+
+	b, err := os.ReadFile("test/contacts.dbf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	memo, err := os.ReadFile("test/contacts.FPT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mem, err := OpenBytes(b, memo, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mem.Close()
+	if records := tableMaps(t, mem); !reflect.DeepEqual(records, expected) {
+		t.Errorf("Expected %v, got %v", expected, records)
+	}
+	if mem.CalculatedRecordCount() != int(mem.Header().RecordCount) {
+		t.Errorf("Expected %d records, calculated %d", mem.Header().RecordCount, mem.CalculatedRecordCount())
+	}
+
+	if _, err := OpenBytes(b, nil, nil); err == nil {
+		t.Errorf("Expected an error for the missing memo file")
+	}
+}
+
+func TestOpenFS(t *testing.T) {
+	tbl, err := OpenFS(os.DirFS("test"), "contacts.dbf", charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+	if err := tbl.ReadDBC(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tbl.FieldByName("last_name"); err != nil {
+		t.Errorf("Expected the long field names of the DBC. %v", err)
+	}
+	if err := tbl.SetOrder("BY_NAME"); err != nil {
+		t.Fatal(err)
+	}
+	if recno, found, err := tbl.Seek("FULLER"); err != nil || !found || recno != 2 {
+		t.Errorf("Expected to find FULLER at 2, got %d %v %v", recno, found, err)
+	}
+
+	// Files of fstest.MapFS do not implement io.ReaderAt
+	mapFS := fstest.MapFS{}
+	for _, name := range []string{"contacts.dbf", "contacts.FPT"} {
+		b, err := os.ReadFile(filepath.Join("test", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		mapFS["data/"+name] = &fstest.MapFile{Data: b}
+	}
+	mem, err := OpenFS(mapFS, "data/contacts.dbf", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mem.Close()
+	if len(tableMaps(t, mem)) != int(mem.Header().RecordCount) {
+		t.Errorf("Expected %d records", mem.Header().RecordCount)
+	}
+}
+
+func TestOpenEmptyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.dbf")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path, nil); err == nil {
+		t.Errorf("Expected an error for an empty file")
+	}
+}
@@ -0,0 +1,545 @@
+package dbf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding"
+)
+
+// ErrReadOnly is returned when modifying a table that was not opened with OpenReadWrite
+var ErrReadOnly = errors.New("Table is opened read-only")
+
+// ErrUnsupportedDialect is returned when creating or modifying a table type that can only be read
+var ErrUnsupportedDialect = errors.New("Writing this table type is not supported")
+
+type writableFile interface {
+	file
+	io.WriterAt
+}
+
+// OpenReadWrite opens the specified DBF for reading and writing.
+// Values are encoded using `encoder`.
+// A nil decoder and encoder use the code page announced by the header, see OpenAuto.
+// An interrupted Pack or Zap is rolled back from the backups before the table is opened.
+func OpenReadWrite(path string, decoder *encoding.Decoder, encoder *encoding.Encoder) (*Dbf, error) {
+	// only writers recover, a reader must not restore the backups while another process packs the table
+	if err := recoverPack(path); err != nil {
+		return nil, fmt.Errorf("Could not recover the interrupted pack of %q. %w", path, err)
+	}
+	dbf, err := openWith(osStorage{writable: true}, path, decoder, nil)
+	if err != nil {
+		return nil, err
+	}
+	if dbf.header.Type.isDBase7() {
+		dbf.Close()
+		return nil, ErrUnsupportedDialect
+	}
+	if encoder != nil {
+		dbf.encoder = encoder
+	}
+	dbf.writable = true
+	return dbf, nil
+}
+
+func (dbf *Dbf) dbfWriter() (writableFile, error) {
+	if !dbf.writable {
+		return nil, ErrReadOnly
+	}
+	w, ok := dbf.dbfFile.(writableFile)
+	if !ok {
+		return nil, ErrReadOnly
+	}
+	return w, nil
+}
+
+// Append appends a new record and returns its record number.
+// `values` maps field names (case insensitive) to their values, missing fields are left blank.
+// Missing autoincrement fields get the next value of their counter, which is advanced by its step.
+func (dbf *Dbf) Append(values map[string]interface{}) (uint32, error) {
+	w, err := dbf.dbfWriter()
+	if err != nil {
+		return 0, err
+	}
+	recno := dbf.header.RecordCount
+	buf := make([]byte, int(dbf.header.RecordLength)+1)
+	buf[0] = ' '
+	for i := range dbf.fields {
+		f := &dbf.fields[i]
+		if (f.Flags & FieldFlagSystem) != 0 {
+			continue
+		}
+		blankField(buf[f.Displacement:f.Displacement+uint32(f.Length)], f)
+		if f.VarLengthSizeIndex != -1 {
+			dbf.setNullFlag(buf, f.VarLengthSizeIndex, true)
+		}
+	}
+	if err := dbf.encodeValues(buf, values); err != nil {
+		return 0, err
+	}
+	auto := dbf.autoIncrements(values)
+	for _, f := range auto {
+		binary.LittleEndian.PutUint32(buf[f.Displacement:], f.NextAutoIncrement)
+	}
+	buf[len(buf)-1] = eofMarker
+
+	if _, err := w.WriteAt(buf, dbf.recordOffset(recno)); err != nil {
+		return 0, fmt.Errorf("Could not write record. %w", err)
+	}
+	dbf.header.RecordCount++
+	if err := dbf.writeHeaderInfo(); err != nil {
+		return 0, err
+	}
+	if err := dbf.advanceAutoIncrements(w, auto); err != nil {
+		return 0, err
+	}
+	return recno, nil
+}
+
+// autoIncrements returns the autoincrement fields that are not set by `values`
+func (dbf *Dbf) autoIncrements(values map[string]interface{}) []*Field {
+	var fields []*Field
+	for i := range dbf.fields {
+		f := &dbf.fields[i]
+		if f.Type != 'I' || (f.Flags&FieldFlagAutoInc) != FieldFlagAutoInc {
+			continue
+		}
+		set := false
+		for name := range values {
+			if dbf.fieldIndex(name) == i {
+				set = true
+				break
+			}
+		}
+		if !set {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// advanceAutoIncrements advances the counters of `fields` by their step and writes their field descriptors
+func (dbf *Dbf) advanceAutoIncrements(w writableFile, fields []*Field) error {
+	buf := make([]byte, 4)
+	for _, f := range fields {
+		step := uint32(f.AutoIncrementStep)
+		if step == 0 {
+			step = 1
+		}
+		f.NextAutoIncrement += step
+		binary.LittleEndian.PutUint32(buf, f.NextAutoIncrement)
+		if _, err := w.WriteAt(buf, int64(32+32*f.Index+19)); err != nil {
+			return fmt.Errorf("Could not write the autoincrement counter of %q. %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// Update changes the fields specified in `values` of the record at `recno`.
+func (dbf *Dbf) Update(recno uint32, values map[string]interface{}) error {
+	w, err := dbf.dbfWriter()
+	if err != nil {
+		return err
+	}
+	if recno >= dbf.header.RecordCount {
+		return ErrInvalidRecordNumber
+	}
+	buf := make([]byte, dbf.header.RecordLength)
+	if _, err := dbf.dbfFile.ReadAt(buf, dbf.recordOffset(recno)); err != nil {
+		return fmt.Errorf("Could not read record. %w", err)
+	}
+	if err := dbf.encodeValues(buf, values); err != nil {
+		return err
+	}
+	if _, err := w.WriteAt(buf, dbf.recordOffset(recno)); err != nil {
+		return fmt.Errorf("Could not write record. %w", err)
+	}
+	return dbf.writeHeaderInfo()
+}
+
+// Delete marks the record at `recno` as deleted
+func (dbf *Dbf) Delete(recno uint32) error {
+	return dbf.setDeleted(recno, true)
+}
+
+// Recall removes the deletion mark of the record at `recno`
+func (dbf *Dbf) Recall(recno uint32) error {
+	return dbf.setDeleted(recno, false)
+}
+
+func (dbf *Dbf) setDeleted(recno uint32, deleted bool) error {
+	w, err := dbf.dbfWriter()
+	if err != nil {
+		return err
+	}
+	if recno >= dbf.header.RecordCount {
+		return ErrInvalidRecordNumber
+	}
+	flag := []byte{' '}
+	if deleted {
+		flag[0] = 0x2A
+	}
+	if _, err := w.WriteAt(flag, dbf.recordOffset(recno)); err != nil {
+		return fmt.Errorf("Could not write record. %w", err)
+	}
+	return dbf.writeHeaderInfo()
+}
+
+func (dbf *Dbf) recordOffset(recno uint32) int64 {
+	return int64(dbf.header.HeaderSize) + int64(recno)*int64(dbf.header.RecordLength)
+}
+
+// writeHeaderInfo writes the last modification date and the record count
+func (dbf *Dbf) writeHeaderInfo() error {
+	w, err := dbf.dbfWriter()
+	if err != nil {
+		return err
+	}
+	dbf.header.setLastModified(time.Now())
+	buf := make([]byte, 7)
+	buf[0] = dbf.header.ModYear
+	buf[1] = dbf.header.ModMonth
+	buf[2] = dbf.header.ModDay
+	binary.LittleEndian.PutUint32(buf[3:], dbf.header.RecordCount)
+	if _, err := w.WriteAt(buf, 1); err != nil {
+		return fmt.Errorf("Could not write header. %w", err)
+	}
+	return nil
+}
+
+func (dbf *Dbf) encodeValues(buf []byte, values map[string]interface{}) error {
+	for name, v := range values {
+		f, err := dbf.FieldByName(name)
+		if err != nil {
+			return err
+		}
+		if (f.Flags & FieldFlagSystem) != 0 {
+			return fmt.Errorf("Field %q is a system field", f.Name)
+		}
+		if err := dbf.encodeField(buf, &f, v); err != nil {
+			return fmt.Errorf("Field %q: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+func (dbf *Dbf) setNullFlag(buf []byte, bit int, set bool) {
+	idx := int(dbf.nullField.Displacement) + bit/8
+	mask := byte(1 << (bit % 8))
+	if set {
+		buf[idx] |= mask
+	} else {
+		buf[idx] &^= mask
+	}
+}
+
+// blankField fills a field with its empty value
+func blankField(b []byte, f *Field) {
+	fill := byte(' ')
+	switch f.Type {
+	case 'I', 'T', 'M', 'G', 'W', 'Y', 'B', 'V', 'Q':
+		if (f.Type != 'M' && f.Type != 'G') || f.Length == 4 {
+			fill = 0x00
+		}
+	}
+	for i := range b {
+		b[i] = fill
+	}
+}
+
+// encodeField writes `v` into the record buffer `buf`
+func (dbf *Dbf) encodeField(buf []byte, f *Field, v interface{}) error {
+	b := buf[f.Displacement : f.Displacement+uint32(f.Length)]
+	if v == nil {
+		if f.NullFieldIndex == -1 {
+			return dbf.encodeField(buf, f, blankValue(f))
+		}
+		blankField(b, f)
+		dbf.setNullFlag(buf, f.NullFieldIndex, true)
+		if f.VarLengthSizeIndex != -1 {
+			dbf.setNullFlag(buf, f.VarLengthSizeIndex, true)
+		}
+		return nil
+	}
+	if f.NullFieldIndex != -1 {
+		dbf.setNullFlag(buf, f.NullFieldIndex, false)
+	}
+
+	switch f.Type {
+	case 'C':
+		s, err := dbf.encodeText(f, v)
+		if err != nil {
+			return err
+		}
+		if len(s) > len(b) {
+			return fmt.Errorf("Value exceeds the field length of %d", f.Length)
+		}
+		n := copy(b, s)
+		for i := n; i < len(b); i++ {
+			b[i] = ' '
+		}
+	case 'V', 'Q':
+		s, err := dbf.encodeText(f, v)
+		if err != nil {
+			return err
+		}
+		if len(s) > len(b) {
+			return fmt.Errorf("Value exceeds the field length of %d", f.Length)
+		}
+		blankField(b, f)
+		copy(b, s)
+		if len(s) < len(b) {
+			b[len(b)-1] = byte(len(s))
+		}
+		dbf.setNullFlag(buf, f.VarLengthSizeIndex, len(s) < len(b))
+	case 'N', 'F':
+		s, err := formatNumber(v, f.DecimalCount)
+		if err != nil {
+			return err
+		}
+		if len(s) > len(b) {
+			return fmt.Errorf("Value %s exceeds the field length of %d", s, f.Length)
+		}
+		pad := len(b) - len(s)
+		for i := 0; i < pad; i++ {
+			b[i] = ' '
+		}
+		copy(b[pad:], s)
+	case 'L':
+		bv, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("Expected bool, got %T", v)
+		}
+		b[0] = 'F'
+		if bv {
+			b[0] = 'T'
+		}
+	case 'D':
+		t, ok := v.(time.Time)
+		if !ok {
+			return fmt.Errorf("Expected time.Time, got %T", v)
+		}
+		if isEmptyDate(t) {
+			copy(b, emptyDateBytes)
+		} else {
+			copy(b, t.Format("20060102"))
+		}
+	case 'T':
+		t, ok := v.(time.Time)
+		if !ok {
+			return fmt.Errorf("Expected time.Time, got %T", v)
+		}
+		binary.LittleEndian.PutUint64(b, timeToJulianDateTime(t))
+	case 'I':
+		i, err := toInt64(v)
+		if err != nil {
+			return err
+		}
+		if i < math.MinInt32 || i > math.MaxInt32 {
+			return fmt.Errorf("Value %d overflows a 32 bit integer", i)
+		}
+		binary.LittleEndian.PutUint32(b, uint32(int32(i)))
+	case 'M':
+		data, err := dbf.encodeText(f, v)
+		if err != nil {
+			return err
+		}
+		return dbf.writeMemo(b, data, memoTypeText)
+	case 'W', 'G':
+		data, err := binaryValue(v)
+		if err != nil {
+			return err
+		}
+		return dbf.writeMemo(b, data, memoTypePicture)
+	case 'Y':
+		c, err := toCurrency(v)
+		if err != nil {
+			return err
+		}
+		binary.LittleEndian.PutUint64(b, uint64(c))
+	case 'B':
+		fv, err := toFloat64(v)
+		if err != nil {
+			return err
+		}
+		binary.LittleEndian.PutUint64(b, math.Float64bits(fv))
+	default:
+		return fmt.Errorf("Writing field type %q is not supported", f.Type)
+	}
+	return nil
+}
+
+// blankValue returns the value that is stored for non nullable fields when writing nil
+func blankValue(f *Field) interface{} {
+	switch f.Type {
+	case 'N', 'F', 'I', 'Y', 'B':
+		return 0
+	case 'L':
+		return false
+	case 'D', 'T':
+		return time.Time{}
+	}
+	return ""
+}
+
+func (dbf *Dbf) encodeString(v interface{}) ([]byte, error) {
+	switch s := v.(type) {
+	case string:
+		if dbf.encoder == nil {
+			return []byte(s), nil
+		}
+		encoded, err := dbf.encoder.Bytes([]byte(s))
+		if err != nil {
+			return nil, fmt.Errorf("Could not encode %q. %w", s, err)
+		}
+		return encoded, nil
+	case []byte:
+		return s, nil
+	}
+	return nil, fmt.Errorf("Expected string, got %T", v)
+}
+
+// encodeText encodes character values, NOCPTRANS fields are stored without encoding
+func (dbf *Dbf) encodeText(f *Field, v interface{}) ([]byte, error) {
+	if f.Type == 'Q' || (f.Flags&FieldFlagBinary) != 0 {
+		return binaryValue(v)
+	}
+	return dbf.encodeString(v)
+}
+
+// binaryValue returns the raw bytes for binary fields without any encoding
+func binaryValue(v interface{}) ([]byte, error) {
+	switch b := v.(type) {
+	case []byte:
+		return b, nil
+	case string:
+		return []byte(b), nil
+	}
+	return nil, fmt.Errorf("Expected []byte, got %T", v)
+}
+
+func toCurrency(v interface{}) (Currency, error) {
+	switch n := v.(type) {
+	case Currency:
+		return n, nil
+	case Decimal:
+		return decimalToCurrency(n)
+	case float32, float64:
+		f, _ := toFloat64(n)
+		scaled := math.Round(f * 10000)
+		// float64(math.MaxInt64) rounds up to 2^63, which does not fit anymore
+		if math.IsNaN(scaled) || scaled >= math.MaxInt64 || scaled < math.MinInt64 {
+			return 0, fmt.Errorf("Value %v overflows a currency", f)
+		}
+		return Currency(scaled), nil
+	case string:
+		d, err := ParseDecimal(n)
+		if err != nil {
+			return 0, err
+		}
+		return decimalToCurrency(d)
+	}
+	i, err := toInt64(v)
+	if err != nil {
+		return 0, err
+	}
+	if i > math.MaxInt64/10000 || i < math.MinInt64/10000 {
+		return 0, fmt.Errorf("Value %d overflows a currency", i)
+	}
+	return Currency(i * 10000), nil
+}
+
+func decimalToCurrency(d Decimal) (Currency, error) {
+	r, err := d.Round(4)
+	if err != nil {
+		return 0, fmt.Errorf("Value %s overflows a currency. %w", d, err)
+	}
+	return Currency(r.Unscaled()), nil
+}
+
+func toFloat64(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float32:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	case Decimal:
+		return n.Float64(), nil
+	case Currency:
+		return n.Float64(), nil
+	}
+	i, err := toInt64(v)
+	if err != nil {
+		return 0, fmt.Errorf("Expected a number, got %T", v)
+	}
+	return float64(i), nil
+}
+
+func isEmptyDate(t time.Time) bool {
+	return t.IsZero() || t.Equal(MinimumDateTime())
+}
+
+func formatNumber(v interface{}, decimals byte) (string, error) {
+	switch n := v.(type) {
+	case float32:
+		return strconv.FormatFloat(float64(n), 'f', int(decimals), 64), nil
+	case float64:
+		return strconv.FormatFloat(n, 'f', int(decimals), 64), nil
+	case Decimal:
+		d, err := n.Round(int(decimals))
+		if err != nil {
+			return "", fmt.Errorf("Could not format %s. %w", n, err)
+		}
+		return d.String(), nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		if err != nil {
+			return "", fmt.Errorf("Invalid number %q", n)
+		}
+		return strconv.FormatFloat(f, 'f', int(decimals), 64), nil
+	}
+	i, err := toInt64(v)
+	if err != nil {
+		return "", err
+	}
+	s := strconv.FormatInt(i, 10)
+	if decimals > 0 {
+		s += "." + strings.Repeat("0", int(decimals))
+	}
+	return s, nil
+}
+
+func toInt64(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), nil
+	case int8:
+		return int64(n), nil
+	case int16:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case uint:
+		return int64(n), nil
+	case uint8:
+		return int64(n), nil
+	case uint16:
+		return int64(n), nil
+	case uint32:
+		return int64(n), nil
+	case uint64:
+		if n > math.MaxInt64 {
+			return 0, fmt.Errorf("Value %d overflows a 64 bit integer", n)
+		}
+		return int64(n), nil
+	}
+	return 0, fmt.Errorf("Expected an integer, got %T", v)
+}
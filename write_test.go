@@ -0,0 +1,350 @@
+package dbf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func createTestTable(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "write.dbf")
+	if err := Create(path, testSchema(), charmap.Windows1252.NewEncoder()); err != nil {
+		t.Fatalf("Could not create table. %v", err)
+	}
+	return path
+}
+
+func openTestTableReadWrite(t *testing.T, path string) *Dbf {
+	t.Helper()
+	tbl, err := OpenReadWrite(path, charmap.Windows1252.NewDecoder(), charmap.Windows1252.NewEncoder())
+	if err != nil {
+		t.Fatalf("Could not open table. %v", err)
+	}
+	return tbl
+}
+
+func recordMap(t *testing.T, tbl *Dbf, recno uint32) (map[string]interface{}, bool) {
+	t.Helper()
+	var m map[string]interface{}
+	var deleted bool
+	err := tbl.RecordAt(recno, func(r *Record) {
+		var err error
+		deleted = r.Deleted()
+		if m, err = r.ToMap(); err != nil {
+			t.Fatalf("Could not read record %d. %v", recno, err)
+		}
+	}, ParseTrimRight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, deleted
+}
+
+func TestAppendUpdateDelete(t *testing.T) {
+	path := createTestTable(t)
+	tbl := openTestTableReadWrite(t, path)
+
+	birthday := time.Date(1980, 5, 17, 0, 0, 0, 0, time.Local)
+	updated := time.Date(2023, 2, 25, 13, 45, 10, 0, time.Local)
+	recno, err := tbl.Append(map[string]interface{}{
+		"id":       42,
+		"NAME":     "Müller",
+		"AMOUNT":   1234.5,
+		"ACTIVE":   true,
+		"BIRTHDAY": birthday,
+		"UPDATED":  updated,
+		"NOTES":    "Some longer memo text",
+		"NICK":     "mü",
+	})
+	if err != nil || recno != 0 {
+		t.Fatalf("Could not append record. %d %v", recno, err)
+	}
+	if _, err := tbl.Append(map[string]interface{}{"ID": 43}); err != nil {
+		t.Fatalf("Could not append record. %v", err)
+	}
+	if _, err := tbl.Append(map[string]interface{}{"UNKNOWN": 43}); err == nil {
+		t.Fatalf("Expected an error for an unknown field")
+	}
+	if err := tbl.Update(1, map[string]interface{}{"NAME": "Second", "UPDATED": nil, "NICK": nil, "NOTES": "x"}); err != nil {
+		t.Fatalf("Could not update record. %v", err)
+	}
+	if err := tbl.Update(0, map[string]interface{}{"NOTES": "short"}); err != nil {
+		t.Fatalf("Could not update record. %v", err)
+	}
+	if err := tbl.Delete(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := tbl.Delete(2); err != ErrInvalidRecordNumber {
+		t.Fatalf("Expected ErrInvalidRecordNumber, got %v", err)
+	}
+	tbl.Close()
+
+	tbl, err = Open(path, charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+	if tbl.Header().RecordCount != 2 || tbl.CalculatedRecordCount() != 2 {
+		t.Fatalf("Expected 2 records, got %d", tbl.Header().RecordCount)
+	}
+	if tbl.Header().LastModified().Day() != time.Now().Day() {
+		t.Errorf("Last modified date was not updated")
+	}
+
+	m, deleted := recordMap(t, tbl, 0)
+	if !deleted {
+		t.Errorf("Record 0 should be deleted")
+	}
+	expected := map[string]interface{}{
+		"ID":       int32(42),
+		"NAME":     "Müller",
+		"AMOUNT":   1234.5,
+		"ACTIVE":   true,
+		"BIRTHDAY": birthday,
+		"UPDATED":  updated,
+		"NOTES":    "short",
+		"NICK":     "mü",
+	}
+	for k, v := range expected {
+		if m[k] != v {
+			t.Errorf("%s: expected %v (%T), got %v (%T)", k, v, v, m[k], m[k])
+		}
+	}
+
+	m, deleted = recordMap(t, tbl, 1)
+	if deleted {
+		t.Errorf("Record 1 should not be deleted")
+	}
+	if m["NAME"] != "Second" || m["UPDATED"] != nil || m["NICK"] != nil || m["NOTES"] != "x" || m["AMOUNT"] != float64(0) {
+		t.Errorf("Unexpected record %v", m)
+	}
+}
+
+func TestRecall(t *testing.T) {
+	tbl := openTestTableReadWrite(t, createTestTable(t))
+	defer tbl.Close()
+
+	if _, err := tbl.Append(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := tbl.Delete(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := tbl.Recall(0); err != nil {
+		t.Fatal(err)
+	}
+	if _, deleted := recordMap(t, tbl, 0); deleted {
+		t.Errorf("Record should not be deleted after Recall")
+	}
+}
+
+func TestAutoIncrement(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auto.dbf")
+	schema := Schema{Fields: []Field{
+		{Name: "ID", Type: 'I', Flags: FieldFlagAutoInc, NextAutoIncrement: 10, AutoIncrementStep: 5},
+		{Name: "NAME", Type: 'C', Length: 10},
+	}}
+	if err := Create(path, schema, charmap.Windows1252.NewEncoder()); err != nil {
+		t.Fatal(err)
+	}
+	tbl := openTestTableReadWrite(t, path)
+	if tbl.Header().Type != TypeVisualFoxProAutoInc {
+		t.Errorf("Expected an autoincrement table, got 0x%02X", byte(tbl.Header().Type))
+	}
+	for _, values := range []map[string]interface{}{{"NAME": "A"}, {"id": 99}, {"NAME": "C"}} {
+		if _, err := tbl.Append(values); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tbl.Close()
+
+	// the counter is persisted in the field descriptor
+	tbl = openTestTableReadWrite(t, path)
+	defer tbl.Close()
+	if _, err := tbl.Append(nil); err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range []int32{10, 99, 15, 20} {
+		if m, _ := recordMap(t, tbl, uint32(i)); m["ID"] != expected {
+			t.Errorf("Record %d: expected ID %d, got %v", i, expected, m["ID"])
+		}
+	}
+	if f, _ := tbl.FieldByName("ID"); f.NextAutoIncrement != 25 {
+		t.Errorf("Expected the next value 25, got %d", f.NextAutoIncrement)
+	}
+}
+
+func TestWriteReadOnly(t *testing.T) {
+	tbl, err := Open(createTestTable(t), charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+	if _, err := tbl.Append(nil); err != ErrReadOnly {
+		t.Fatalf("Expected ErrReadOnly, got %v", err)
+	}
+}
+
+func TestCurrencyDoubleAndBinaryFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "types.dbf")
+	schema := Schema{Fields: []Field{
+		{Name: "PRICE", Type: 'Y'},
+		{Name: "RATIO", Type: 'B', DecimalCount: 2},
+		{Name: "SCORE", Type: 'F', Length: 8, DecimalCount: 3},
+		{Name: "HASH", Type: 'Q', Length: 8},
+		{Name: "BLOB", Type: 'W'},
+		{Name: "PICTURE", Type: 'G'},
+	}}
+	if err := Create(path, schema, charmap.Windows1252.NewEncoder()); err != nil {
+		t.Fatal(err)
+	}
+	tbl := openTestTableReadWrite(t, path)
+	defer tbl.Close()
+
+	blob := []byte{0x00, 0xFF, 0x1A, 0x0D}
+	_, err := tbl.Append(map[string]interface{}{
+		"PRICE":   NewDecimal(-1999, 2),
+		"RATIO":   1.0 / 3,
+		"SCORE":   -1.5,
+		"HASH":    []byte{1, 2, 3},
+		"BLOB":    blob,
+		"PICTURE": blob[:2],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, _ := recordMap(t, tbl, 0)
+	if m["PRICE"] != Currency(-199900) || m["PRICE"].(Currency).String() != "-19.9900" {
+		t.Errorf("Unexpected currency %v", m["PRICE"])
+	}
+	if m["RATIO"] != 1.0/3 || m["SCORE"] != -1.5 {
+		t.Errorf("Unexpected numbers %v %v", m["RATIO"], m["SCORE"])
+	}
+	if !bytes.Equal(m["HASH"].([]byte), []byte{1, 2, 3}) {
+		t.Errorf("Unexpected varbinary %v", m["HASH"])
+	}
+	if !bytes.Equal(m["BLOB"].([]byte), blob) || !bytes.Equal(m["PICTURE"].([]byte), blob[:2]) {
+		t.Errorf("Unexpected blobs %v %v", m["BLOB"], m["PICTURE"])
+	}
+
+	err = tbl.RecordAt(0, func(r *Record) {
+		if v, _ := r.Field("RATIO"); v != NewDecimal(33, 2) {
+			t.Errorf("Expected 0.33, got %v", v)
+		}
+	}, ParseExactDecimals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Doubles that a Decimal can not hold stay float64
+	for i, v := range []float64{math.Inf(1), 1e300} {
+		if _, err := tbl.Append(map[string]interface{}{"RATIO": v}); err != nil {
+			t.Fatal(err)
+		}
+		err = tbl.RecordAt(uint32(i+1), func(r *Record) {
+			if got, err := r.Field("RATIO"); err != nil || got != v {
+				t.Errorf("Expected %v, got %v %v", v, got, err)
+			}
+		}, ParseExactDecimals)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := tbl.Append(map[string]interface{}{"RATIO": math.NaN()}); err != nil {
+		t.Fatal(err)
+	}
+	err = tbl.RecordAt(3, func(r *Record) {
+		if got, err := r.Field("RATIO"); err != nil || !math.IsNaN(got.(float64)) {
+			t.Errorf("Expected NaN, got %v %v", got, err)
+		}
+	}, ParseExactDecimals)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []interface{}{1e15, -1e15, math.NaN(), math.Inf(-1)} {
+		if _, err := tbl.Append(map[string]interface{}{"PRICE": v}); err == nil {
+			t.Errorf("Expected an overflow error for %v", v)
+		}
+	}
+}
+
+func TestManyNullableFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nulls.dbf")
+	var fields []Field
+	for i := 0; i < 10; i++ {
+		fields = append(fields, Field{Name: fmt.Sprintf("V%d", i), Type: 'V', Length: 10, Flags: FieldFlagNull})
+	}
+	fields = append(fields, Field{Name: "LAST", Type: 'I', Flags: FieldFlagNull})
+	if err := Create(path, Schema{Fields: fields}, charmap.Windows1252.NewEncoder()); err != nil {
+		t.Fatal(err)
+	}
+	tbl := openTestTableReadWrite(t, path)
+	defer tbl.Close()
+
+	if tbl.nullField.Length != 3 {
+		t.Fatalf("Expected 3 bytes of null flags, got %d", tbl.nullField.Length)
+	}
+	values := map[string]interface{}{"LAST": nil}
+	for i := 0; i < 10; i++ {
+		if i%3 == 0 {
+			values[fmt.Sprintf("V%d", i)] = nil
+		} else {
+			values[fmt.Sprintf("V%d", i)] = strings.Repeat("x", i)
+		}
+	}
+	if _, err := tbl.Append(values); err != nil {
+		t.Fatal(err)
+	}
+	m, _ := recordMap(t, tbl, 0)
+	for k, v := range values {
+		if m[k] != v {
+			t.Errorf("%s: expected %v, got %v", k, v, m[k])
+		}
+	}
+	err := tbl.RecordAt(0, func(r *Record) {
+		if v, err := r.FieldAt(9); err != nil || v != nil {
+			t.Errorf("Expected NULL from FieldAt, got %v %v", v, err)
+		}
+		if v, err := r.Field("LAST"); err != nil || v != nil {
+			t.Errorf("Expected NULL from Field, got %v %v", v, err)
+		}
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBinaryFlagSkipsTranscoding(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nocptrans.dbf")
+	schema := Schema{Fields: []Field{
+		{Name: "TOKEN", Type: 'C', Length: 4, Flags: FieldFlagBinary},
+		{Name: "SHORT", Type: 'V', Length: 6, Flags: FieldFlagBinary},
+		{Name: "BLOB", Type: 'M', Flags: FieldFlagBinary},
+		{Name: "TEXT", Type: 'C', Length: 2},
+	}}
+	if err := Create(path, schema, charmap.Windows1252.NewEncoder()); err != nil {
+		t.Fatal(err)
+	}
+	tbl := openTestTableReadWrite(t, path)
+	defer tbl.Close()
+
+	raw := []byte{0xFC, 0x81, 0x00, 0x20}
+	if _, err := tbl.Append(map[string]interface{}{"TOKEN": raw, "SHORT": raw[:2], "BLOB": raw, "TEXT": "ü"}); err != nil {
+		t.Fatal(err)
+	}
+	m, _ := recordMap(t, tbl, 0)
+	if !bytes.Equal(m["TOKEN"].([]byte), raw) || !bytes.Equal(m["SHORT"].([]byte), raw[:2]) || !bytes.Equal(m["BLOB"].([]byte), raw) {
+		t.Errorf("Binary fields were transcoded: %v", m)
+	}
+	if m["TEXT"] != "ü" {
+		t.Errorf("Expected ü, got %v", m["TEXT"])
+	}
+}
@@ -0,0 +1,95 @@
+package dbf
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"golang.org/x/text/encoding"
+)
+
+// zipStorage opens files inside a ZIP archive.
+// Stored entries are read directly from the archive, compressed entries are decompressed into memory.
+type zipStorage struct {
+	fsStorage
+	archive *zip.Reader
+	r       io.ReaderAt
+}
+
+func (s zipStorage) open(name string) (file, error) {
+	for _, f := range s.archive.File {
+		if f.Name != name || f.Method != zip.Store {
+			continue
+		}
+		offset, err := f.DataOffset()
+		if err != nil {
+			return nil, fmt.Errorf("Could not read %q. %w", name, err)
+		}
+		return newReaderFile(name, io.NewSectionReader(s.r, offset, int64(f.UncompressedSize64)), int64(f.UncompressedSize64), nil), nil
+	}
+	return s.fsStorage.open(name)
+}
+
+// find returns the name of the entry `name`.
+// Names are matched case-insensitively, a name without directory also matches a unique entry in any directory.
+func (s zipStorage) find(name string) (string, error) {
+	name = strings.TrimPrefix(strings.ReplaceAll(name, `\`, "/"), "/")
+	var matches []string
+	for _, f := range s.archive.File {
+		switch {
+		case f.Name == name:
+			return f.Name, nil
+		case strings.EqualFold(f.Name, name):
+			matches = append([]string{f.Name}, matches...)
+		case !strings.Contains(name, "/") && strings.EqualFold(path.Base(f.Name), name):
+			matches = append(matches, f.Name)
+		}
+	}
+	switch {
+	case len(matches) == 0:
+		return "", fmt.Errorf("Table %q not found in archive", name)
+	case len(matches) > 1 && !strings.EqualFold(matches[0], name):
+		return "", fmt.Errorf("Table %q is ambiguous in archive: %s", name, strings.Join(matches, ", "))
+	}
+	return matches[0], nil
+}
+
+// OpenZip opens the table `name` inside the ZIP archive at `zipPath` without extracting it.
+// The memo file, indexes and the DBC are looked up inside the archive.
+// A nil decoder detects the encoding from the header's code page mark.
+func OpenZip(zipPath, name string, decoder *encoding.Decoder) (*Dbf, error) {
+	f, err := os.Open(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	dbf, err := OpenZipReader(f, stat.Size(), name, decoder)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	dbf.closer = f
+	return dbf, nil
+}
+
+// OpenZipReader opens the table `name` inside the ZIP archive read from `r` with `size` bytes.
+// See OpenZip.
+func OpenZipReader(r io.ReaderAt, size int64, name string, decoder *encoding.Decoder) (*Dbf, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read archive. %w", err)
+	}
+	s := zipStorage{fsStorage: fsStorage{fsys: archive}, archive: archive, r: r}
+	name, err = s.find(name)
+	if err != nil {
+		return nil, err
+	}
+	return openWith(s, name, decoder, nil)
+}
@@ -0,0 +1,81 @@
+package dbf
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func createTestZip(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "export.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	files := []string{"contacts.dbf", "contacts.FPT", "contacts.cdx", "contacts.dbc", "contacts.dct", "contacts.dcx"}
+	for i, name := range files {
+		b, err := os.ReadFile(filepath.Join("test", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// mix stored and compressed entries
+		method := zip.Store
+		if i%2 == 1 {
+			method = zip.Deflate
+		}
+		entry, err := w.CreateHeader(&zip.FileHeader{Name: "DATA/" + name, Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := entry.Write(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOpenZip(t *testing.T) {
+	path := createTestZip(t)
+	tbl, err := OpenZip(path, "CONTACTS.DBF", charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tbl.Close()
+
+	if err := tbl.ReadDBC(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tbl.FieldByName("last_name"); err != nil {
+		t.Errorf("Expected the long field names of the DBC. %v", err)
+	}
+	if err := tbl.SetOrder("BY_NAME"); err != nil {
+		t.Fatal(err)
+	}
+	if recno, found, err := tbl.Seek("FULLER"); err != nil || !found || recno != 2 {
+		t.Errorf("Expected to find FULLER at 2, got %d %v %v", recno, found, err)
+	}
+
+	disk, err := Open("test/contacts.dbf", charmap.Windows1252.NewDecoder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer disk.Close()
+	disk.ReadDBC()
+	if expected, records := tableMaps(t, disk), tableMaps(t, tbl); !reflect.DeepEqual(records, expected) {
+		t.Errorf("Expected %v, got %v", expected, records)
+	}
+
+	if _, err := OpenZip(path, "missing.dbf", nil); err == nil {
+		t.Errorf("Expected an error for a missing table")
+	}
+}